@@ -0,0 +1,219 @@
+package postgres_test
+
+import (
+	"context"
+	"rsshub/domain"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpsertArticlesCounts(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	if err := repo.AddFeed(ctx, "feed", "https://example.com/feed", 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	article := func(link, title string) domain.Article {
+		return domain.Article{Title: title, Link: link, Description: "d", PublishedAt: published}
+	}
+	outcome := domain.PollOutcome{NextPoll: time.Hour, Validators: domain.CacheValidators{ETag: `"v1"`}}
+
+	c, err := repo.UpsertArticles(ctx, f.ID, []domain.Article{
+		article("https://example.com/a", "A"),
+		article("https://example.com/b", "B"),
+		article("https://example.com/a", "A again"),
+	}, outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (domain.UpsertCounts{Inserted: 2}) {
+		t.Fatalf("first batch: got %+v, want 2 inserted", c)
+	}
+
+	c, err = repo.UpsertArticles(ctx, f.ID, []domain.Article{
+		article("https://example.com/a", "A"),
+		article("https://example.com/b", "B changed"),
+		article("https://example.com/c", "C"),
+	}, outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (domain.UpsertCounts{Inserted: 1, Updated: 1, Unchanged: 1}) {
+		t.Fatalf("second batch: got %+v, want 1 inserted, 1 updated, 1 unchanged", c)
+	}
+
+	f, err = repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Validators.ETag != `"v1"` || f.NextPollAt.IsZero() {
+		t.Fatalf("feed not marked polled with the batch: %+v", f)
+	}
+
+	arts, err := repo.ListArticlesByFeed(ctx, f.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range arts {
+		revs, err := repo.ListArticleRevisions(ctx, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 0
+		if a.Link == "https://example.com/b" {
+			want = 1
+		}
+		if len(revs) != want {
+			t.Fatalf("%s has %d revisions, want %d", a.Link, len(revs), want)
+		}
+		if want == 1 && (revs[0].Title != "B" || a.Title != "B changed") {
+			t.Fatalf("revision of %s: got %q, current %q", a.Link, revs[0].Title, a.Title)
+		}
+	}
+}
+
+func TestUndatedArticleKeepsFirstSeen(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	if err := repo.AddFeed(ctx, "feed", "https://example.com/feed", 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	undated := []domain.Article{{Title: "A", Link: "https://example.com/a", Description: "d"}}
+	var first time.Time
+	for i := 0; i < 2; i++ {
+		c, err := repo.UpsertArticles(ctx, f.ID, undated, domain.PollOutcome{NextPoll: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 && c != (domain.UpsertCounts{Unchanged: 1}) {
+			t.Fatalf("second poll: got %+v, want 1 unchanged", c)
+		}
+		arts, err := repo.ListArticlesByFeed(ctx, f.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := arts[0]
+		if !a.PublishedAt.Equal(a.FirstSeenAt) {
+			t.Fatalf("published %v, want first seen %v", a.PublishedAt, a.FirstSeenAt)
+		}
+		if i == 0 {
+			first = a.PublishedAt
+		} else if !a.PublishedAt.Equal(first) {
+			t.Fatalf("published moved from %v to %v", first, a.PublishedAt)
+		}
+	}
+}
+
+func TestArticlesKeyedByGUID(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	if err := repo.AddFeed(ctx, "feed", "https://example.com/feed", 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	outcome := domain.PollOutcome{NextPoll: time.Hour}
+
+	// stored by link, as before GUIDs were kept
+	if _, err := repo.UpsertArticles(ctx, f.ID, []domain.Article{
+		{Title: "A", Link: "https://example.com/a?utm=1", Description: "d", PublishedAt: published},
+	}, outcome); err != nil {
+		t.Fatal(err)
+	}
+	article := func(link string) domain.Article {
+		return domain.Article{GUID: "urn:a", Title: "A", Link: link, Description: "d", PublishedAt: published,
+			Authors: []string{"Jane Doe"}, Categories: []string{"Go", "Databases"},
+			Enclosures: []domain.Enclosure{{Kind: domain.EnclosureFile, URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 1234, Duration: time.Minute, Episode: 3}}}
+	}
+	for _, link := range []string{"https://example.com/a?utm=1", "https://example.com/a?utm=2"} {
+		c, err := repo.UpsertArticles(ctx, f.ID, []domain.Article{article(link)}, outcome)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Inserted != 0 {
+			t.Fatalf("link %s: got %+v, want the stored article reused", link, c)
+		}
+	}
+
+	arts, err := repo.ListArticles(ctx, domain.ArticleFilter{Category: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arts) != 1 || arts[0].GUID != "urn:a" || len(arts[0].Authors) != 1 || len(arts[0].Categories) != 2 {
+		t.Fatalf("by category: got %+v", arts)
+	}
+	if want := article("").Enclosures; len(arts[0].Enclosures) != 1 || arts[0].Enclosures[0] != want[0] {
+		t.Fatalf("enclosures: got %+v, want %+v", arts[0].Enclosures, want)
+	}
+	if arts, err := repo.ListArticles(ctx, domain.ArticleFilter{Author: "someone else"}); err != nil || len(arts) != 0 {
+		t.Fatalf("by unknown author: got %d articles, err %v", len(arts), err)
+	}
+}
+
+func TestRevisionKeepsMetadata(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	if err := repo.AddFeed(ctx, "feed", "https://example.com/feed", 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome := domain.PollOutcome{NextPoll: time.Hour}
+	a := domain.Article{
+		GUID: "urn:1", Title: "A", Link: "https://example.com/a", PublishedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Comments: "https://example.com/a#comments", Authors: []string{"Ann", "Bob"}, Categories: []string{"news"},
+		Enclosures: []domain.Enclosure{{Kind: domain.EnclosureFile, URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 1000, Duration: 90 * time.Second, Episode: 3}},
+	}
+	if _, err := repo.UpsertArticles(ctx, f.ID, []domain.Article{a}, outcome); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the metadata changes, which still makes a revision
+	changed := a
+	changed.Authors = []string{"Ann"}
+	changed.Enclosures = nil
+	c, err := repo.UpsertArticles(ctx, f.ID, []domain.Article{changed}, outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Updated != 1 {
+		t.Fatalf("got %+v, want the article updated", c)
+	}
+
+	arts, err := repo.ListArticlesByFeed(ctx, f.ID, 0)
+	if err != nil || len(arts) != 1 {
+		t.Fatalf("got %d articles, err %v", len(arts), err)
+	}
+	revs, err := repo.ListArticleRevisions(ctx, arts[0].ID)
+	if err != nil || len(revs) != 1 {
+		t.Fatalf("got %d revisions, err %v", len(revs), err)
+	}
+	rev := revs[0]
+	if rev.Comments != a.Comments || !slices.Equal(rev.Authors, a.Authors) || !slices.Equal(rev.Categories, a.Categories) {
+		t.Fatalf("revision metadata: %+v", rev)
+	}
+	if !slices.Equal(rev.Enclosures, a.Enclosures) {
+		t.Fatalf("revision enclosures %+v, want %+v", rev.Enclosures, a.Enclosures)
+	}
+}
@@ -0,0 +1,41 @@
+package postgres_test
+
+import (
+	"context"
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+func TestUpdateFeedIntervalHonoursHints(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	if err := repo.AddFeed(ctx, "feed", "https://example.com/feed", 0); err != nil {
+		t.Fatal(err)
+	}
+	f, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MarkFeedPolled(ctx, f.ID, domain.PollOutcome{NextPoll: 10 * time.Hour, Hints: domain.ScheduleHints{TTL: 2 * time.Hour}}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interval := time.Minute
+	if _, err := repo.UpdateFeed(ctx, "feed", domain.FeedUpdate{PollInterval: &interval}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := repo.GetFeedByName(ctx, "feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pulled forward from 10h to the 2h ttl, not to the new minute
+	if pulled := before.NextPollAt.Sub(after.NextPollAt); pulled < 7*time.Hour || pulled > 8*time.Hour+time.Minute {
+		t.Fatalf("next poll pulled forward by %v, want about 8h", pulled)
+	}
+}
@@ -0,0 +1,193 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"rsshub/adapter/postgres"
+	"rsshub/adapter/rss"
+	"rsshub/app"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// testDB connects to the database named by RSSHUB_TEST_DATABASE_URL and
+// empties it. The tests are skipped without one; never point it at a
+// database whose feeds you want to keep.
+func testDB(t *testing.T) *postgres.Repository {
+	t.Helper()
+	dsn := os.Getenv("RSSHUB_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("RSSHUB_TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	repo := postgres.New(db)
+	if err := repo.Ensure(ctx); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `TRUNCATE feeds CASCADE`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return repo
+}
+
+// hitCounter serves a one-item RSS feed at every path and counts the
+// requests per path.
+type hitCounter struct {
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func (h *hitCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.hits[r.URL.Path]++
+	h.mu.Unlock()
+	// slow enough for the instances to compete for feeds
+	time.Sleep(20 * time.Millisecond)
+	w.Header().Set("Content-Type", "application/rss+xml")
+	fmt.Fprintf(w, `<rss version="2.0"><channel><title>t</title><item><title>a</title><link>https://example.com%s/a</link><pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate></item></channel></rss>`, r.URL.Path)
+}
+
+func (h *hitCounter) snapshot() map[string]int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string]int, len(h.hits))
+	for k, v := range h.hits {
+		out[k] = v
+	}
+	return out
+}
+
+func TestAggregatorsShareFeeds(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	counter := &hitCounter{hits: make(map[string]int)}
+	srv := httptest.NewServer(counter)
+	defer srv.Close()
+
+	const feeds = 30
+	for i := 0; i < feeds; i++ {
+		// a long interval, so every feed is due exactly once during the test
+		if err := repo.AddFeed(ctx, fmt.Sprintf("feed-%d", i), fmt.Sprintf("%s/feed-%d", srv.URL, i), time.Hour); err != nil {
+			t.Fatalf("add feed: %v", err)
+		}
+	}
+
+	var aggs []*app.AggregatorService
+	for i := 0; i < 3; i++ {
+		agg := app.NewAggregator(repo, rss.NewHTTPFetcher(), time.Hour, 2, app.Options{
+			Heartbeat:     20 * time.Millisecond,
+			InstanceID:    fmt.Sprintf("test-%d", i),
+			LeaseDuration: time.Minute,
+		})
+		if err := agg.Start(ctx); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		aggs = append(aggs, agg)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(counter.snapshot()) < feeds && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// give a double fetch the chance to show up
+	time.Sleep(200 * time.Millisecond)
+
+	for _, agg := range aggs {
+		if err := agg.Stop(); err != nil {
+			t.Errorf("stop: %v", err)
+		}
+	}
+
+	hits := counter.snapshot()
+	if len(hits) != feeds {
+		t.Fatalf("fetched %d of %d feeds", len(hits), feeds)
+	}
+	for path, n := range hits {
+		if n != 1 {
+			t.Errorf("%s fetched %d times, want 1", path, n)
+		}
+	}
+
+	all, err := repo.ListFeeds(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range all {
+		if f.NextPollAt.IsZero() || f.LastError != "" {
+			t.Errorf("feed %s not marked polled: next poll %v, last error %q", f.Name, f.NextPollAt, f.LastError)
+		}
+	}
+}
+
+func TestExpiredLeaseIsReclaimed(t *testing.T) {
+	repo := testDB(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := repo.AddFeed(ctx, fmt.Sprintf("feed-%d", i), fmt.Sprintf("https://example.com/feed-%d", i), 0); err != nil {
+			t.Fatalf("add feed: %v", err)
+		}
+	}
+
+	// an instance claims everything and crashes without releasing
+	claimed, err := repo.ClaimDueFeeds(ctx, "crashed", 0, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 3 {
+		t.Fatalf("crashed instance claimed %d feeds, want 3", len(claimed))
+	}
+
+	claimed, err = repo.ClaimDueFeeds(ctx, "survivor", 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Fatalf("claimed %d leased feeds, want 0", len(claimed))
+	}
+	f, err := repo.GetFeedByName(ctx, "feed-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := repo.ClaimFeed(ctx, f.ID, "survivor", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("claimed a feed leased to another instance")
+	}
+
+	time.Sleep(time.Second)
+	claimed, err = repo.ClaimDueFeeds(ctx, "survivor", 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("claimed %d expired feeds with limit 2, want 2", len(claimed))
+	}
+
+	if err := repo.ReleaseFeeds(ctx, "survivor"); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = repo.ClaimDueFeeds(ctx, "other", 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 3 {
+		t.Fatalf("claimed %d feeds after release, want 3", len(claimed))
+	}
+}
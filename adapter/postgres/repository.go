@@ -2,14 +2,55 @@ package postgres
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"rsshub/domain"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
-type Repository struct{ db *sql.DB }
+type Repository struct {
+	db      *sql.DB
+	metrics domain.Metrics
+	log     *slog.Logger
+}
+
+const feedColumns = `id, created_at, updated_at, name, url, etag, last_modified, last_error, next_attempt_at, disabled_at, disabled_reason, consecutive_failures, poll_interval_seconds, next_poll_at, learned_interval_seconds,
+ttl_seconds, update_period_seconds, skip_hours, skip_days`
+
+const articleColumns = `id, created_at, updated_at, guid, title, link, published_at, first_seen_at, description, content, comments, feed_id`
+
+func New(db *sql.DB) *Repository { return &Repository{db: db, log: slog.Default()} }
+
+// WithMetrics makes r report the latency of every operation to m.
+func (r *Repository) WithMetrics(m domain.Metrics) *Repository {
+	r.metrics = m
+	return r
+}
+
+// WithLogger makes r log every operation to l at debug level.
+func (r *Repository) WithLogger(l *slog.Logger) *Repository {
+	r.log = l
+	return r
+}
 
-func New(db *sql.DB) *Repository { return &Repository{db: db} }
+// observe reports the latency of operation op started at start. It is
+// meant to be deferred at the top of each method.
+func (r *Repository) observe(op string, start time.Time) {
+	d := time.Since(start)
+	if r.metrics != nil {
+		r.metrics.DBOperation(op, d)
+	}
+	r.log.Debug("db operation", "op", op, "duration", d)
+}
 
 func (r *Repository) Ensure(ctx context.Context) error {
 	_, err := r.db.ExecContext(ctx, `
@@ -32,6 +73,100 @@ CREATE TABLE IF NOT EXISTS articles (
     feed_id UUID NOT NULL REFERENCES feeds(id),
     UNIQUE (feed_id, link)
 );
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS etag TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_modified TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS poll_interval_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS next_poll_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS learned_interval_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS ttl_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS update_period_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS skip_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS skip_days INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS lease_owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
+CREATE TABLE IF NOT EXISTS fetch_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    http_status INT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    items_parsed INT NOT NULL DEFAULT 0,
+    items_new INT NOT NULL DEFAULT 0,
+    items_updated INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS fetch_attempts_feed_started_idx ON fetch_attempts (feed_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS fetch_attempts_started_idx ON fetch_attempts (started_at);
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_hash TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP NOT NULL,
+    content_hash TEXT NOT NULL DEFAULT '',
+    stored_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS article_revisions_article_idx ON article_revisions (article_id, replaced_at DESC);
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'articles' AND column_name = 'first_seen_at') THEN
+        ALTER TABLE articles ADD COLUMN first_seen_at TIMESTAMP NOT NULL DEFAULT now();
+        UPDATE articles SET first_seen_at = created_at;
+    END IF;
+END $$;
+ALTER TABLE fetch_attempts ADD COLUMN IF NOT EXISTS truncated TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS guid TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS comments TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'articles' AND column_name = 'item_key') THEN
+        ALTER TABLE articles ADD COLUMN item_key TEXT;
+        UPDATE articles SET item_key = link;
+        ALTER TABLE articles ALTER COLUMN item_key SET NOT NULL;
+    END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS articles_feed_item_key_idx ON articles (feed_id, item_key);
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS articles_feed_id_link_key;
+CREATE INDEX IF NOT EXISTS articles_feed_link_idx ON articles (feed_id, link);
+CREATE TABLE IF NOT EXISTS article_authors (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (article_id, position)
+);
+CREATE INDEX IF NOT EXISTS article_authors_name_idx ON article_authors (lower(name));
+CREATE TABLE IF NOT EXISTS article_categories (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (article_id, position)
+);
+CREATE INDEX IF NOT EXISTS article_categories_name_idx ON article_categories (lower(name));
+CREATE TABLE IF NOT EXISTS article_enclosures (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    kind TEXT NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    length BIGINT NOT NULL DEFAULT 0,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    episode INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (article_id, position)
+);
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS comments TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS authors TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS enclosures JSONB NOT NULL DEFAULT '[]';
 `)
 	if err != nil {
 		return err
@@ -40,12 +175,60 @@ CREATE TABLE IF NOT EXISTS articles (
 	return nil
 }
 
-func (r *Repository) AddFeed(ctx context.Context, name, url string) error {
-	_, err := r.db.ExecContext(ctx, `INSERT INTO feeds (name, url) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`, name, url)
+func (r *Repository) AddFeed(ctx context.Context, name, url string, pollInterval time.Duration) error {
+	defer r.observe("add_feed", time.Now())
+	_, err := r.db.ExecContext(ctx, `INSERT INTO feeds (name, url, poll_interval_seconds) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING`, name, url, int64(pollInterval/time.Second))
 	return err
 }
 
+func (r *Repository) UpdateFeed(ctx context.Context, name string, u domain.FeedUpdate) (int64, error) {
+	defer r.observe("update_feed", time.Now())
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sets := []string{`updated_at = now()`}
+	args := []any{name}
+	if u.URL != nil {
+		args = append(args, *u.URL)
+		sets = append(sets, fmt.Sprintf(`url = $%d`, len(args)))
+	}
+	if u.PollInterval != nil {
+		args = append(args, int64(*u.PollInterval/time.Second))
+		sets = append(sets, fmt.Sprintf(`poll_interval_seconds = $%d::int`, len(args)))
+	}
+	if u.PollInterval != nil && *u.PollInterval > 0 {
+		f, err := scanFeed(tx.QueryRowContext(ctx, `SELECT `+feedColumns+` FROM feeds WHERE name = $1 FOR UPDATE`, name))
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		// pull the next poll forward if the new interval is shorter, but
+		// no earlier than the feed's schedule hints allow; the delay is
+		// added to now() in SQL to share the clock of ClaimDueFeeds
+		args = append(args, f.Hints.Delay(*u.PollInterval, time.Now()).Seconds())
+		sets = append(sets, fmt.Sprintf(`next_poll_at = CASE WHEN next_poll_at IS NOT NULL THEN LEAST(next_poll_at, now() + make_interval(secs => $%d::float8)) END`, len(args)))
+	}
+	if u.Enable {
+		sets = append(sets, `disabled_at = NULL`, `disabled_reason = ''`, `consecutive_failures = 0`, `next_attempt_at = NULL`, `last_error = ''`)
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE feeds SET `+strings.Join(sets, ", ")+` WHERE name = $1`, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
 func (r *Repository) DeleteFeed(ctx context.Context, name string) (int64, error) {
+	defer r.observe("delete_feed", time.Now())
 	res, err := r.db.ExecContext(ctx, `DELETE FROM feeds WHERE name = $1`, name)
 	if err != nil {
 		return 0, err
@@ -60,7 +243,8 @@ func (r *Repository) DeleteFeed(ctx context.Context, name string) (int64, error)
 }
 
 func (r *Repository) ListFeeds(ctx context.Context, limit int) ([]domain.Feed, error) {
-	q := `SELECT id, created_at, updated_at, name, url FROM feeds ORDER BY created_at DESC`
+	defer r.observe("list_feeds", time.Now())
+	q := `SELECT ` + feedColumns + ` FROM feeds ORDER BY created_at DESC`
 	if limit > 0 {
 		q += ` LIMIT $1`
 		return scanFeeds(r.db.QueryContext(ctx, q, limit))
@@ -69,38 +253,563 @@ func (r *Repository) ListFeeds(ctx context.Context, limit int) ([]domain.Feed, e
 }
 
 func (r *Repository) GetFeedByName(ctx context.Context, name string) (domain.Feed, error) {
-	var f domain.Feed
-	row := r.db.QueryRowContext(ctx, `SELECT id, created_at, updated_at, name, url FROM feeds WHERE name = $1`, name)
-	if err := row.Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt, &f.Name, &f.URL); err != nil {
-		return domain.Feed{}, err
-	}
-	return f, nil
+	defer r.observe("get_feed_by_name", time.Now())
+	row := r.db.QueryRowContext(ctx, `SELECT `+feedColumns+` FROM feeds WHERE name = $1`, name)
+	return scanFeed(row)
 }
 
 func (r *Repository) ListArticlesByFeed(ctx context.Context, feedID string, limit int) ([]domain.Article, error) {
-	q := `SELECT id, created_at, updated_at, title, link, published_at, description, feed_id FROM articles WHERE feed_id = $1 ORDER BY published_at DESC, created_at DESC`
-	if limit > 0 {
-		q += ` LIMIT $2`
-		return scanArticles(r.db.QueryContext(ctx, q, feedID, limit))
+	return r.ListArticles(ctx, domain.ArticleFilter{FeedID: feedID, Limit: limit})
+}
+
+func (r *Repository) ListArticles(ctx context.Context, f domain.ArticleFilter) ([]domain.Article, error) {
+	defer r.observe("list_articles", time.Now())
+	q := `SELECT ` + articleColumns + ` FROM articles WHERE true`
+	var args []any
+	if f.FeedID != "" {
+		args = append(args, f.FeedID)
+		q += fmt.Sprintf(` AND feed_id = $%d`, len(args))
+	}
+	if f.Author != "" {
+		args = append(args, f.Author)
+		q += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM article_authors n WHERE n.article_id = articles.id AND lower(n.name) = lower($%d))`, len(args))
+	}
+	if f.Category != "" {
+		args = append(args, f.Category)
+		q += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM article_categories n WHERE n.article_id = articles.id AND lower(n.name) = lower($%d))`, len(args))
+	}
+	q += ` ORDER BY published_at DESC, created_at DESC`
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		q += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	arts, err := scanArticles(r.db.QueryContext(ctx, q, args...))
+	if err != nil {
+		return nil, err
+	}
+	return arts, r.loadArticleDetails(ctx, arts)
+}
+
+// upsertBatchRows keeps a multi-row insert well below Postgres' limit of
+// 65535 parameters.
+const upsertBatchRows = 1000
+
+func (r *Repository) UpsertArticles(ctx context.Context, feedID string, articles []domain.Article, o domain.PollOutcome) (domain.UpsertCounts, error) {
+	defer r.observe("upsert_articles", time.Now())
+	// a statement may not update the same row twice
+	articles = uniqueKeys(articles)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.UpsertCounts{}, err
+	}
+	defer tx.Rollback()
+
+	if err := adoptGUIDs(ctx, tx, feedID, articles); err != nil {
+		return domain.UpsertCounts{}, err
+	}
+	var c domain.UpsertCounts
+	for start := 0; start < len(articles); start += upsertBatchRows {
+		batch := articles[start:min(start+upsertBatchRows, len(articles))]
+		inserted, updated, err := upsertArticleBatch(ctx, tx, feedID, batch)
+		if err != nil {
+			return domain.UpsertCounts{}, err
+		}
+		c.Inserted += inserted
+		c.Updated += updated
+	}
+	c.Unchanged = len(articles) - c.Inserted - c.Updated
+
+	if err := markFeedPolled(ctx, tx, feedID, o); err != nil {
+		return domain.UpsertCounts{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.UpsertCounts{}, err
+	}
+	return c, nil
+}
+
+// upsertArticleBatch stores articles with one statement. An article whose
+// content hash is unchanged is neither updated nor returned, which is how
+// unchanged articles are told apart; rows stored before hashes existed are
+// compared field by field instead. The version an update replaces is
+// copied to article_revisions first, together with its authors,
+// categories and enclosures, which are rewritten after.
+func upsertArticleBatch(ctx context.Context, tx *sql.Tx, feedID string, articles []domain.Article) (inserted, updated int, err error) {
+	const cols = 9
+	var values strings.Builder
+	args := make([]any, 1, 1+len(articles)*cols)
+	args[0] = feedID
+	byKey := make(map[string]domain.Article, len(articles))
+	for i, a := range articles {
+		if i > 0 {
+			values.WriteString(",")
+		}
+		n := 1 + i*cols
+		fmt.Fprintf(&values, "($%d::text,$%d::text,$%d::text,$%d::text,$%d::timestamp,$%d::text,$%d::text,$%d::text,$%d::text)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+		args = append(args, articleKey(a), a.GUID, a.Title, a.Link, sql.NullTime{Time: a.PublishedAt, Valid: !a.PublishedAt.IsZero()},
+			a.Description, a.Content, a.Comments, contentHash(a))
+		byKey[articleKey(a)] = a
+	}
+
+	// an article without a usable date keeps the time it was first seen
+	q := `WITH fetched (item_key, guid, title, link, published_at, description, content, comments, content_hash) AS (VALUES ` + values.String() + `),
+incoming AS (
+    SELECT f.item_key, f.guid, f.title, f.link, COALESCE(f.published_at, a.first_seen_at, now()::timestamp) AS published_at,
+        f.description, f.content, f.comments, f.content_hash
+    FROM fetched f LEFT JOIN articles a ON a.feed_id = $1::uuid AND a.item_key = f.item_key
+),
+revised AS (
+    INSERT INTO article_revisions (article_id, title, description, content, comments, authors, categories, enclosures, published_at, content_hash, stored_at)
+    SELECT a.id, a.title, a.description, a.content, a.comments,
+        ARRAY(SELECT name FROM article_authors WHERE article_id = a.id ORDER BY position),
+        ARRAY(SELECT name FROM article_categories WHERE article_id = a.id ORDER BY position),
+        COALESCE((SELECT jsonb_agg(jsonb_build_object('kind', kind, 'url', url, 'type', type, 'length', length,
+            'duration_seconds', duration_seconds, 'episode', episode) ORDER BY position)
+            FROM article_enclosures WHERE article_id = a.id), '[]'),
+        a.published_at, a.content_hash, a.updated_at
+    FROM articles a JOIN incoming i ON a.feed_id = $1::uuid AND a.item_key = i.item_key
+    WHERE ` + contentChanged("a", "i") + `
+)
+INSERT INTO articles (item_key, guid, title, link, published_at, description, content, comments, feed_id, content_hash)
+SELECT item_key, guid, title, link, published_at, description, content, comments, $1::uuid, content_hash FROM incoming
+ON CONFLICT (feed_id, item_key) DO UPDATE SET title = EXCLUDED.title, link = EXCLUDED.link, description = EXCLUDED.description,
+    content = EXCLUDED.content, comments = EXCLUDED.comments, published_at = EXCLUDED.published_at,
+    content_hash = EXCLUDED.content_hash, updated_at = now()
+WHERE ` + contentChanged("articles", "EXCLUDED") + `
+RETURNING id, item_key, (xmax = 0)`
+
+	rows, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	var ids []string
+	var authors, categories [][]string
+	var enclosures [][]domain.Enclosure
+	for rows.Next() {
+		// xmax is only zero on a freshly inserted row version
+		var id, key string
+		var isNew bool
+		if err := rows.Scan(&id, &key, &isNew); err != nil {
+			return 0, 0, err
+		}
+		if isNew {
+			inserted++
+		} else {
+			updated++
+		}
+		ids = append(ids, id)
+		authors = append(authors, byKey[key].Authors)
+		categories = append(categories, byKey[key].Categories)
+		enclosures = append(enclosures, byKey[key].Enclosures)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	rows.Close()
+
+	if err := replaceArticleNames(ctx, tx, "article_authors", ids, authors); err != nil {
+		return 0, 0, err
+	}
+	if err := replaceArticleNames(ctx, tx, "article_categories", ids, categories); err != nil {
+		return 0, 0, err
+	}
+	if err := replaceArticleEnclosures(ctx, tx, ids, enclosures); err != nil {
+		return 0, 0, err
+	}
+	return inserted, updated, nil
+}
+
+// replaceArticleNames stores names[i] as the rows of table for article
+// ids[i], in place of the ones stored before. Blank and repeated names are
+// left out.
+func replaceArticleNames(ctx context.Context, tx *sql.Tx, table string, ids []string, names [][]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE article_id = ANY($1::uuid[])`, pq.Array(ids)); err != nil {
+		return err
+	}
+	var rowIDs, rowNames []string
+	var positions []int64
+	for i, id := range ids {
+		seen := make(map[string]bool, len(names[i]))
+		for _, name := range names[i] {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			rowIDs = append(rowIDs, id)
+			positions = append(positions, int64(len(seen)))
+			rowNames = append(rowNames, name)
+		}
+	}
+	if len(rowIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO `+table+` (article_id, position, name) SELECT * FROM unnest($1::uuid[], $2::int[], $3::text[])`,
+		pq.Array(rowIDs), pq.Array(positions), pq.Array(rowNames))
+	return err
+}
+
+// replaceArticleEnclosures stores enclosures[i] as the enclosures of
+// article ids[i], in place of the ones stored before.
+func replaceArticleEnclosures(ctx context.Context, tx *sql.Tx, ids []string, enclosures [][]domain.Enclosure) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM article_enclosures WHERE article_id = ANY($1::uuid[])`, pq.Array(ids)); err != nil {
+		return err
+	}
+	var rowIDs, kinds, urls, types []string
+	var positions, lengths, durations, episodes []int64
+	for i, id := range ids {
+		for j, e := range enclosures[i] {
+			rowIDs = append(rowIDs, id)
+			positions = append(positions, int64(j+1))
+			kinds = append(kinds, e.Kind)
+			urls = append(urls, e.URL)
+			types = append(types, e.Type)
+			lengths = append(lengths, e.Length)
+			durations = append(durations, int64(e.Duration/time.Second))
+			episodes = append(episodes, int64(e.Episode))
+		}
+	}
+	if len(rowIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO article_enclosures (article_id, position, kind, url, type, length, duration_seconds, episode)
+SELECT * FROM unnest($1::uuid[], $2::int[], $3::text[], $4::text[], $5::text[], $6::bigint[], $7::int[], $8::int[])`,
+		pq.Array(rowIDs), pq.Array(positions), pq.Array(kinds), pq.Array(urls), pq.Array(types), pq.Array(lengths), pq.Array(durations), pq.Array(episodes))
+	return err
+}
+
+// adoptGUIDs rekeys articles that were stored by their link, before the
+// GUIDs of their feed were kept, to the GUID that now comes with the same
+// link, so they are updated rather than stored a second time.
+func adoptGUIDs(ctx context.Context, tx *sql.Tx, feedID string, articles []domain.Article) error {
+	var guids, links []string
+	for _, a := range articles {
+		if a.GUID != "" {
+			guids = append(guids, a.GUID)
+			links = append(links, a.Link)
+		}
+	}
+	if len(guids) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE articles a SET guid = g.guid, item_key = g.guid
+FROM unnest($2::text[], $3::text[]) AS g (guid, link)
+WHERE a.feed_id = $1 AND a.guid = '' AND a.link = g.link AND a.item_key = a.link
+    AND NOT EXISTS (SELECT 1 FROM articles b WHERE b.feed_id = $1 AND b.item_key = g.guid)`, feedID, pq.Array(guids), pq.Array(links))
+	return err
+}
+
+// articleKey identifies a within its feed: by GUID when it has one, since
+// some publishers add rotating tracking parameters to their links.
+func articleKey(a domain.Article) string {
+	if a.GUID != "" {
+		return a.GUID
+	}
+	return a.Link
+}
+
+// contentChanged is the SQL condition under which the incoming version of
+// an article differs from the stored one.
+func contentChanged(stored, incoming string) string {
+	return `CASE WHEN ` + stored + `.content_hash = '' THEN (` + stored + `.title, ` + stored + `.description, ` + stored + `.published_at) IS DISTINCT FROM (` +
+		incoming + `.title, ` + incoming + `.description, ` + incoming + `.published_at) ELSE ` + stored + `.content_hash <> ` + incoming + `.content_hash END`
+}
+
+// contentHash identifies the content of an article that is kept in its
+// revisions. The link is left out, so that rotating tracking parameters
+// do not count as a change.
+func contentHash(a domain.Article) string {
+	h := sha256.New()
+	h.Write([]byte(a.Title))
+	h.Write([]byte{0})
+	h.Write([]byte(a.Description))
+	h.Write([]byte{0})
+	// the column keeps microseconds
+	h.Write([]byte(a.PublishedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)))
+	// only hashed when present, so the hashes of articles stored before
+	// these were kept stay the same
+	if a.Content != "" || a.Comments != "" || len(a.Authors) > 0 || len(a.Categories) > 0 {
+		for _, v := range []string{a.Content, a.Comments, strings.Join(a.Authors, "\x1f"), strings.Join(a.Categories, "\x1f")} {
+			h.Write([]byte{0})
+			h.Write([]byte(v))
+		}
+	}
+	for _, e := range a.Enclosures {
+		fmt.Fprintf(h, "\x00%s\x1f%s\x1f%s\x1f%d\x1f%d\x1f%d", e.Kind, e.URL, e.Type, e.Length, e.Duration/time.Second, e.Episode)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r *Repository) GetArticle(ctx context.Context, id string) (domain.Article, error) {
+	defer r.observe("get_article", time.Now())
+	arts, err := scanArticles(r.db.QueryContext(ctx, `SELECT `+articleColumns+` FROM articles WHERE id = $1`, id))
+	if err != nil {
+		return domain.Article{}, err
+	}
+	if len(arts) == 0 {
+		return domain.Article{}, sql.ErrNoRows
+	}
+	return arts[0], r.loadArticleDetails(ctx, arts)
+}
+
+// loadArticleDetails fills in the authors, categories and enclosures of
+// arts.
+func (r *Repository) loadArticleDetails(ctx context.Context, arts []domain.Article) error {
+	if len(arts) == 0 {
+		return nil
+	}
+	ids := make([]string, len(arts))
+	index := make(map[string]int, len(arts))
+	for i, a := range arts {
+		ids[i] = a.ID
+		index[a.ID] = i
+	}
+	for table, field := range map[string]func(*domain.Article) *[]string{
+		"article_authors":    func(a *domain.Article) *[]string { return &a.Authors },
+		"article_categories": func(a *domain.Article) *[]string { return &a.Categories },
+	} {
+		rows, err := r.db.QueryContext(ctx, `SELECT article_id, name FROM `+table+` WHERE article_id = ANY($1::uuid[]) ORDER BY article_id, position`, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id, name string
+			if err := rows.Scan(&id, &name); err != nil {
+				rows.Close()
+				return err
+			}
+			names := field(&arts[index[id]])
+			*names = append(*names, name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT article_id, kind, url, type, length, duration_seconds, episode FROM article_enclosures
+WHERE article_id = ANY($1::uuid[]) ORDER BY article_id, position`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var e domain.Enclosure
+		var duration int64
+		if err := rows.Scan(&id, &e.Kind, &e.URL, &e.Type, &e.Length, &duration, &e.Episode); err != nil {
+			return err
+		}
+		e.Duration = time.Duration(duration) * time.Second
+		a := &arts[index[id]]
+		a.Enclosures = append(a.Enclosures, e)
+	}
+	return rows.Err()
+}
+
+func (r *Repository) ListArticleRevisions(ctx context.Context, articleID string) ([]domain.ArticleRevision, error) {
+	defer r.observe("list_article_revisions", time.Now())
+	rows, err := r.db.QueryContext(ctx, `SELECT id, article_id, title, description, content, comments, authors, categories, enclosures,
+    published_at, stored_at, replaced_at FROM article_revisions
+WHERE article_id = $1 ORDER BY replaced_at DESC`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.ArticleRevision
+	for rows.Next() {
+		var rev domain.ArticleRevision
+		var enclosures []byte
+		if err := rows.Scan(&rev.ID, &rev.ArticleID, &rev.Title, &rev.Description, &rev.Content, &rev.Comments,
+			pq.Array(&rev.Authors), pq.Array(&rev.Categories), &enclosures, &rev.PublishedAt, &rev.StoredAt, &rev.ReplacedAt); err != nil {
+			return nil, err
+		}
+		var stored []revisionEnclosure
+		if err := json.Unmarshal(enclosures, &stored); err != nil {
+			return nil, fmt.Errorf("enclosures of revision %s: %w", rev.ID, err)
+		}
+		for _, e := range stored {
+			rev.Enclosures = append(rev.Enclosures, domain.Enclosure{Kind: e.Kind, URL: e.URL, Type: e.Type, Length: e.Length,
+				Duration: time.Duration(e.DurationSeconds) * time.Second, Episode: e.Episode})
+		}
+		out = append(out, rev)
+	}
+	return out, rows.Err()
+}
+
+// revisionEnclosure is an enclosure as kept in article_revisions, where
+// the rows of article_enclosures are stored as a JSON array.
+type revisionEnclosure struct {
+	Kind            string `json:"kind"`
+	URL             string `json:"url"`
+	Type            string `json:"type"`
+	Length          int64  `json:"length"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	Episode         int    `json:"episode"`
+}
+
+// uniqueKeys drops articles whose key appeared earlier in the list.
+func uniqueKeys(articles []domain.Article) []domain.Article {
+	seen := make(map[string]struct{}, len(articles))
+	out := make([]domain.Article, 0, len(articles))
+	for _, a := range articles {
+		if _, ok := seen[articleKey(a)]; ok {
+			continue
+		}
+		seen[articleKey(a)] = struct{}{}
+		out = append(out, a)
+	}
+	return out
+}
+
+// ClaimDueFeeds leases due feeds to owner. Rows being claimed by another
+// instance at the same moment are skipped rather than waited for, and a
+// lease that has expired, because its owner crashed, no longer protects
+// the feed.
+func (r *Repository) ClaimDueFeeds(ctx context.Context, owner string, limit int, lease time.Duration) ([]domain.Feed, error) {
+	defer r.observe("claim_due_feeds", time.Now())
+	// LIMIT NULL is no limit
+	feeds, err := scanFeeds(r.db.QueryContext(ctx, `WITH due AS MATERIALIZED (
+    SELECT id FROM feeds
+    WHERE disabled_at IS NULL
+      AND (next_poll_at IS NULL OR next_poll_at <= now())
+      AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+      AND (lease_expires_at IS NULL OR lease_expires_at <= now())
+    ORDER BY next_poll_at ASC NULLS FIRST, created_at ASC
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE feeds SET lease_owner = $1, lease_expires_at = now() + make_interval(secs => $2::float8)
+WHERE id IN (SELECT id FROM due)
+RETURNING `+feedColumns, owner, lease.Seconds(), sql.NullInt64{Int64: int64(limit), Valid: limit > 0}))
+	if err != nil {
+		return nil, err
 	}
-	return scanArticles(r.db.QueryContext(ctx, q, feedID))
+	// RETURNING does not keep the order of the CTE
+	sort.SliceStable(feeds, func(i, j int) bool {
+		a, b := feeds[i], feeds[j]
+		if a.NextPollAt.Equal(b.NextPollAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.NextPollAt.Before(b.NextPollAt)
+	})
+	return feeds, nil
+}
+
+func (r *Repository) ClaimFeed(ctx context.Context, feedID, owner string, lease time.Duration) (bool, error) {
+	defer r.observe("claim_feed", time.Now())
+	res, err := r.db.ExecContext(ctx, `UPDATE feeds SET lease_owner = $2, lease_expires_at = now() + make_interval(secs => $3::float8)
+WHERE id = $1 AND (lease_expires_at IS NULL OR lease_expires_at <= now() OR lease_owner = $2)`, feedID, owner, lease.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *Repository) ReleaseFeed(ctx context.Context, feedID, owner string) error {
+	defer r.observe("release_feed", time.Now())
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET lease_owner = '', lease_expires_at = NULL WHERE id = $1 AND lease_owner = $2`, feedID, owner)
+	return err
+}
+
+func (r *Repository) ReleaseFeeds(ctx context.Context, owner string) error {
+	defer r.observe("release_feeds", time.Now())
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET lease_owner = '', lease_expires_at = NULL WHERE lease_owner = $1`, owner)
+	return err
+}
+
+func (r *Repository) MarkFeedPolled(ctx context.Context, feedID string, o domain.PollOutcome) error {
+	defer r.observe("mark_feed_polled", time.Now())
+	return markFeedPolled(ctx, r.db, feedID, o)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func markFeedPolled(ctx context.Context, db execer, feedID string, o domain.PollOutcome) error {
+	_, err := db.ExecContext(ctx, `UPDATE feeds SET updated_at = now(), etag = $2, last_modified = $3, last_error = '', next_attempt_at = NULL, consecutive_failures = 0,
+next_poll_at = now() + make_interval(secs => $4::float8), learned_interval_seconds = $5,
+ttl_seconds = $6, update_period_seconds = $7, skip_hours = $8, skip_days = $9 WHERE id = $1`,
+		feedID, o.Validators.ETag, o.Validators.LastModified, o.NextPoll.Seconds(), int64(o.LearnedInterval/time.Second),
+		int64(o.Hints.TTL/time.Second), int64(o.Hints.UpdatePeriod/time.Second), int64(o.Hints.SkipHours), int64(o.Hints.SkipDays))
+	return err
+}
+
+func (r *Repository) PublishStats(ctx context.Context, feedID string, window int) (domain.PublishStats, error) {
+	defer r.observe("publish_stats", time.Now())
+	var st domain.PublishStats
+	var oldest, newest sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT count(*), min(published_at), max(published_at) FROM (
+    SELECT published_at FROM articles WHERE feed_id = $1 ORDER BY published_at DESC LIMIT $2
+) recent`, feedID, window).Scan(&st.Count, &oldest, &newest)
+	if err != nil {
+		return domain.PublishStats{}, err
+	}
+	st.Oldest = oldest.Time
+	st.Newest = newest.Time
+	return st, nil
 }
 
-func (r *Repository) UpsertArticle(ctx context.Context, a domain.Article) error {
-	_, err := r.db.ExecContext(ctx, `INSERT INTO articles (title, link, published_at, description, feed_id) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (feed_id, link) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description, published_at = EXCLUDED.published_at, updated_at = now()`, a.Title, a.Link, a.PublishedAt, a.Description, a.FeedID)
+func (r *Repository) MarkFeedFailed(ctx context.Context, feedID, reason string, retryAfter time.Duration) error {
+	defer r.observe("mark_feed_failed", time.Now())
+	// next_attempt_at is computed in SQL so it shares now()'s clock and
+	// time zone with the ClaimDueFeeds comparison.
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET updated_at = now(), last_error = $2, consecutive_failures = consecutive_failures + 1,
+next_attempt_at = CASE WHEN $3::float8 > 0 THEN now() + make_interval(secs => $3::float8) ELSE NULL END
+WHERE id = $1`, feedID, reason, retryAfter.Seconds())
 	return err
 }
 
-func (r *Repository) GetStaleFeeds(ctx context.Context, limit int) ([]domain.Feed, error) {
-	q := `SELECT id, created_at, updated_at, name, url FROM feeds ORDER BY updated_at ASC, created_at ASC LIMIT $1`
-	return scanFeeds(r.db.QueryContext(ctx, q, limit))
+func (r *Repository) UpdateFeedURL(ctx context.Context, feedID, url string) error {
+	defer r.observe("update_feed_url", time.Now())
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET url = $2 WHERE id = $1`, feedID, url)
+	return err
 }
 
-func (r *Repository) MarkFeedPolled(ctx context.Context, feedID string) error {
-	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET updated_at = now() WHERE id = $1`, feedID)
+func (r *Repository) DisableFeed(ctx context.Context, feedID, reason string) error {
+	defer r.observe("disable_feed", time.Now())
+	_, err := r.db.ExecContext(ctx, `UPDATE feeds SET updated_at = now(), disabled_at = now(), disabled_reason = $2, last_error = $2 WHERE id = $1`, feedID, reason)
 	return err
 }
 
+func (r *Repository) RecordFetchAttempt(ctx context.Context, fa domain.FetchAttempt) error {
+	defer r.observe("record_fetch_attempt", time.Now())
+	_, err := r.db.ExecContext(ctx, `INSERT INTO fetch_attempts (feed_id, started_at, finished_at, http_status, bytes, items_parsed, items_new, items_updated, truncated, error) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
+		fa.FeedID, fa.StartedAt, fa.FinishedAt, fa.StatusCode, fa.Bytes, fa.ItemsParsed, fa.ItemsNew, fa.ItemsUpdated, fa.Truncated, fa.Error)
+	return err
+}
+
+func (r *Repository) ListFetchAttempts(ctx context.Context, feedID string, limit int) ([]domain.FetchAttempt, error) {
+	defer r.observe("list_fetch_attempts", time.Now())
+	q := `SELECT id, feed_id, started_at, finished_at, http_status, bytes, items_parsed, items_new, items_updated, truncated, error FROM fetch_attempts WHERE feed_id = $1 ORDER BY started_at DESC`
+	if limit > 0 {
+		q += ` LIMIT $2`
+		return scanFetchAttempts(r.db.QueryContext(ctx, q, feedID, limit))
+	}
+	return scanFetchAttempts(r.db.QueryContext(ctx, q, feedID))
+}
+
+func (r *Repository) PruneFetchAttempts(ctx context.Context, cutoff time.Time) (int64, error) {
+	defer r.observe("prune_fetch_attempts", time.Now())
+	res, err := r.db.ExecContext(ctx, `DELETE FROM fetch_attempts WHERE started_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func scanFeeds(rows *sql.Rows, err error) ([]domain.Feed, error) {
 	if err != nil {
 		return nil, err
@@ -108,13 +817,42 @@ func scanFeeds(rows *sql.Rows, err error) ([]domain.Feed, error) {
 	defer rows.Close()
 	var out []domain.Feed
 	for rows.Next() {
-		var f domain.Feed
-		if err := rows.Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt, &f.Name, &f.URL); err != nil {
+		f, err := scanFeed(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, f)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFeed reads a row selected with feedColumns.
+func scanFeed(row rowScanner) (domain.Feed, error) {
+	var f domain.Feed
+	var nextAttempt, disabled, nextPoll sql.NullTime
+	var intervalSecs, learnedSecs, ttlSecs, periodSecs, skipHours, skipDays int64
+	if err := row.Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt, &f.Name, &f.URL, &f.Validators.ETag, &f.Validators.LastModified,
+		&f.LastError, &nextAttempt, &disabled, &f.DisabledReason, &f.ConsecutiveFailures, &intervalSecs, &nextPoll, &learnedSecs,
+		&ttlSecs, &periodSecs, &skipHours, &skipDays); err != nil {
+		return domain.Feed{}, err
+	}
+	f.NextAttemptAt = nextAttempt.Time
+	f.DisabledAt = disabled.Time
+	f.PollInterval = time.Duration(intervalSecs) * time.Second
+	f.NextPollAt = nextPoll.Time
+	f.LearnedInterval = time.Duration(learnedSecs) * time.Second
+	f.Hints = domain.ScheduleHints{
+		TTL:          time.Duration(ttlSecs) * time.Second,
+		UpdatePeriod: time.Duration(periodSecs) * time.Second,
+		SkipHours:    uint32(skipHours),
+		SkipDays:     uint8(skipDays),
+	}
+	return f, nil
 }
 
 func scanArticles(rows *sql.Rows, err error) ([]domain.Article, error) {
@@ -125,12 +863,28 @@ func scanArticles(rows *sql.Rows, err error) ([]domain.Article, error) {
 	var out []domain.Article
 	for rows.Next() {
 		var a domain.Article
-		if err := rows.Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt, &a.Title, &a.Link, &a.PublishedAt, &a.Description, &a.FeedID); err != nil {
+		if err := rows.Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt, &a.GUID, &a.Title, &a.Link, &a.PublishedAt, &a.FirstSeenAt, &a.Description, &a.Content, &a.Comments, &a.FeedID); err != nil {
 			return nil, err
 		}
 		out = append(out, a)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+func scanFetchAttempts(rows *sql.Rows, err error) ([]domain.FetchAttempt, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.FetchAttempt
+	for rows.Next() {
+		var fa domain.FetchAttempt
+		if err := rows.Scan(&fa.ID, &fa.FeedID, &fa.StartedAt, &fa.FinishedAt, &fa.StatusCode, &fa.Bytes, &fa.ItemsParsed, &fa.ItemsNew, &fa.ItemsUpdated, &fa.Truncated, &fa.Error); err != nil {
+			return nil, err
+		}
+		out = append(out, fa)
+	}
+	return out, rows.Err()
 }
 
 // Utility: optional timeout wrapper
@@ -0,0 +1,51 @@
+package prometheus
+
+import "time"
+
+var (
+	durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20}
+	sizeBuckets     = []float64{1 << 10, 8 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+	dbBuckets       = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+)
+
+// Metrics implements domain.Metrics on top of a Registry.
+type Metrics struct {
+	fetches      *CounterVec
+	articles     *CounterVec
+	fetchSeconds *HistogramVec
+	fetchBytes   *HistogramVec
+	dbSeconds    *HistogramVec
+}
+
+// NewMetrics registers the aggregator's metric families on r.
+func NewMetrics(r *Registry) *Metrics {
+	return &Metrics{
+		fetches: r.NewCounterVec("rsshub_fetches_total",
+			"Feed fetches by outcome.", "feed", "outcome"),
+		articles: r.NewCounterVec("rsshub_articles_stored_total",
+			"Articles stored, by whether they were inserted or updated.", "feed", "result"),
+		fetchSeconds: r.NewHistogramVec("rsshub_fetch_duration_seconds",
+			"Time spent fetching and storing a feed.", durationBuckets, "feed"),
+		fetchBytes: r.NewHistogramVec("rsshub_fetch_response_bytes",
+			"Size of feed response bodies.", sizeBuckets, "feed"),
+		dbSeconds: r.NewHistogramVec("rsshub_db_operation_duration_seconds",
+			"Latency of repository operations.", dbBuckets, "op"),
+	}
+}
+
+func (m *Metrics) FetchCompleted(feed, outcome string, d time.Duration, bytes int64) {
+	m.fetches.Inc(feed, outcome)
+	m.fetchSeconds.Observe(d.Seconds(), feed)
+	if bytes > 0 {
+		m.fetchBytes.Observe(float64(bytes), feed)
+	}
+}
+
+func (m *Metrics) ArticlesStored(feed string, inserted, updated int) {
+	m.articles.Add(float64(inserted), feed, "inserted")
+	m.articles.Add(float64(updated), feed, "updated")
+}
+
+func (m *Metrics) DBOperation(op string, d time.Duration) {
+	m.dbSeconds.Observe(d.Seconds(), op)
+}
@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("polls_total", "Feed polls.", "feed")
+	h := r.NewHistogramVec("poll_seconds", "Poll latency.", []float64{0.1, 1}, "feed")
+	r.NewGaugeFunc("queue_length", "Jobs waiting.\nIncludes refreshes.", func() float64 { return 3 })
+
+	c.Inc("zeta")
+	c.Add(2, "alpha")
+	c.Inc("a \\ \"b\"\nc")
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		h.Observe(v, "bbc")
+	}
+	h.Observe(0.5, "abc")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP polls_total Feed polls.
+# TYPE polls_total counter
+polls_total{feed="a \\ \"b\"\nc"} 1
+polls_total{feed="alpha"} 2
+polls_total{feed="zeta"} 1
+# HELP poll_seconds Poll latency.
+# TYPE poll_seconds histogram
+poll_seconds_bucket{feed="abc",le="0.1"} 0
+poll_seconds_bucket{feed="abc",le="1"} 1
+poll_seconds_bucket{feed="abc",le="+Inf"} 1
+poll_seconds_sum{feed="abc"} 0.5
+poll_seconds_count{feed="abc"} 1
+poll_seconds_bucket{feed="bbc",le="0.1"} 2
+poll_seconds_bucket{feed="bbc",le="1"} 3
+poll_seconds_bucket{feed="bbc",le="+Inf"} 4
+poll_seconds_sum{feed="bbc"} 2.65
+poll_seconds_count{feed="bbc"} 4
+# HELP queue_length Jobs waiting.\nIncludes refreshes.
+# TYPE queue_length gauge
+queue_length 3
+`
+	if got := b.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// a second scrape renders the same series in the same order
+	var again strings.Builder
+	if err := r.WriteText(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != want {
+		t.Fatalf("second scrape differs:\n%s", again.String())
+	}
+}
+
+func TestHistogramWithoutLabels(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("size_bytes", "Body size.", []float64{10})
+	h.Observe(20)
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`size_bytes_bucket{le="10"} 0`,
+		`size_bytes_bucket{le="+Inf"} 1`,
+		`size_bytes_sum 20`,
+		`size_bytes_count 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, b.String())
+		}
+	}
+}
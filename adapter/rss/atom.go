@@ -0,0 +1,148 @@
+package rss
+
+import (
+	"encoding/xml"
+	"rsshub/domain"
+	"strings"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name            `xml:"feed"`
+	Title   atomText            `xml:"title"`
+	Entry   xmlItems[atomEntry] `xml:"entry"`
+	syndication
+}
+
+type atomEntry struct {
+	mediaExtensions
+	ID        string         `xml:"id"`
+	Title     atomText       `xml:"title"`
+	Link      []atomLink     `xml:"link"`
+	Summary   atomText       `xml:"summary"`
+	Content   atomText       `xml:"content"`
+	Published string         `xml:"published"`
+	Updated   string         `xml:"updated"`
+	Author    []atomPerson   `xml:"author"`
+	Category  []atomCategory `xml:"category"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+// atomCategory is an Atom category. Term identifies it, so it is what
+// articles are filtered by; label is only a human-readable form of it.
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomText is an Atom text construct. For type="xhtml" the payload is
+// markup wrapped in a <div>, so the inner XML is kept verbatim; text and
+// html payloads are plain character data.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func parseAtom(dec *xml.Decoder, root *xml.StartElement, list *itemList) (domain.ScheduleHints, error) {
+	var af atomFeed
+	af.Entry.itemList = list
+	err := dec.DecodeElement(&af, root)
+	return hintsFrom("", af.syndication, nil, nil), err
+}
+
+func (e atomEntry) item() domain.FetchedItem {
+	content := e.Content.String()
+	description := e.Summary.String()
+	if description == "" {
+		description = content
+	}
+	var authors, categories []string
+	for _, a := range e.Author {
+		authors = append(authors, firstNonEmpty(a.Name, a.Email))
+	}
+	for _, c := range e.Category {
+		categories = append(categories, firstNonEmpty(c.Term, c.Label))
+	}
+	var files []domain.Enclosure
+	for _, l := range e.Link {
+		if l.Rel == "enclosure" {
+			files = append(files, domain.Enclosure{Kind: domain.EnclosureFile, URL: l.Href, Type: l.Type, Length: parseCount(l.Length)})
+		}
+	}
+	return domain.FetchedItem{
+		GUID:        strings.TrimSpace(e.ID),
+		Title:       e.Title.String(),
+		Link:        e.link(),
+		Description: description,
+		Content:     content,
+		PublishedAt: e.published(),
+		Authors:     authors,
+		Categories:  categories,
+		Comments:    e.replies(),
+		Enclosures:  e.enclosures(files...),
+	}
+}
+
+// replies returns the entry's comments page: an HTML link of relation
+// "replies" (RFC 4685).
+func (e atomEntry) replies() string {
+	for _, l := range e.Link {
+		if l.Rel == "replies" && (l.Type == "" || l.Type == "text/html") {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+// link picks the entry's alternate link, preferring HTML representations.
+// A missing rel attribute means "alternate" per RFC 4287. Entries without
+// any usable link fall back to their id so they still have a stable key.
+func (e atomEntry) link() string {
+	var alternate string
+	for _, l := range e.Link {
+		if l.Href == "" || (l.Rel != "" && l.Rel != "alternate") {
+			continue
+		}
+		if l.Type == "" || l.Type == "text/html" {
+			return strings.TrimSpace(l.Href)
+		}
+		if alternate == "" {
+			alternate = l.Href
+		}
+	}
+	if alternate != "" {
+		return strings.TrimSpace(alternate)
+	}
+	if len(e.Link) > 0 && e.Link[0].Href != "" {
+		return strings.TrimSpace(e.Link[0].Href)
+	}
+	return strings.TrimSpace(e.ID)
+}
+
+func (e atomEntry) published() time.Time {
+	for _, s := range []string{e.Published, e.Updated} {
+		if p, ok := parseDate(s); ok {
+			return p
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,71 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAtom(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		entry       string
+		link        string
+		description string
+		content     string
+		published   time.Time
+	}{
+		{"html alternate first",
+			`<id>urn:1</id><link rel="self" href="https://example.com/self"/><link rel="alternate" type="application/xml" href="https://example.com/1.xml"/><link rel="alternate" type="text/html" href="https://example.com/1"/>`,
+			"https://example.com/1", "", "", time.Time{}},
+		{"no rel is alternate",
+			`<id>urn:1</id><link rel="enclosure" href="https://example.com/1.mp3"/><link href="https://example.com/1"/>`,
+			"https://example.com/1", "", "", time.Time{}},
+		{"other alternate",
+			`<id>urn:1</id><link rel="alternate" type="application/pdf" href="https://example.com/1.pdf"/>`,
+			"https://example.com/1.pdf", "", "", time.Time{}},
+		{"first link without alternate",
+			`<id>urn:1</id><link rel="related" href="https://example.com/related"/>`,
+			"https://example.com/related", "", "", time.Time{}},
+		{"id without links", `<id>urn:1</id>`, "urn:1", "", "", time.Time{}},
+		{"summary and content",
+			`<id>urn:1</id><summary>Short</summary><content type="html">&lt;p&gt;Long&lt;/p&gt;</content>`,
+			"urn:1", "Short", "<p>Long</p>", time.Time{}},
+		{"content only",
+			`<id>urn:1</id><content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Long</p></div></content>`,
+			"urn:1", `<div xmlns="http://www.w3.org/1999/xhtml"><p>Long</p></div>`, `<div xmlns="http://www.w3.org/1999/xhtml"><p>Long</p></div>`, time.Time{}},
+		{"published over updated",
+			`<id>urn:1</id><published>2024-05-01T12:00:00Z</published><updated>2024-05-02T08:30:00Z</updated>`,
+			"urn:1", "", "", published},
+		{"updated only",
+			`<id>urn:1</id><updated>2024-05-02T10:30:00+02:00</updated>`,
+			"urn:1", "", "", updated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<feed xmlns="http://www.w3.org/2005/Atom"><title>Feed</title><entry><title>Entry</title>` + tt.entry + `</entry></feed>`
+			res, err := parse("application/atom+xml", strings.NewReader(doc), Limits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != 1 {
+				t.Fatalf("got %d items", len(res.Items))
+			}
+			it := res.Items[0]
+			if it.GUID != "urn:1" || it.Title != "Entry" {
+				t.Errorf("guid %q, title %q", it.GUID, it.Title)
+			}
+			if it.Link != tt.link {
+				t.Errorf("link %q, want %q", it.Link, tt.link)
+			}
+			if it.Description != tt.description || it.Content != tt.content {
+				t.Errorf("description %q, content %q; want %q, %q", it.Description, it.Content, tt.description, tt.content)
+			}
+			if !it.PublishedAt.Equal(tt.published) {
+				t.Errorf("published %v, want %v", it.PublishedAt, tt.published)
+			}
+		})
+	}
+}
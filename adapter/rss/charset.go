@@ -0,0 +1,231 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// singleByteCharsets maps the labels publishers use for a charset to its
+// table.
+var singleByteCharsets = map[string]*[128]rune{}
+
+func init() {
+	for table, labels := range map[*[128]rune]string{
+		&windows1250: "windows-1250 cp1250 x-cp1250",
+		&windows1251: "windows-1251 cp1251 x-cp1251 win-1251",
+		&windows1252: "windows-1252 cp1252 x-cp1252 iso-8859-1 iso8859-1 iso_8859-1 latin1 l1 us-ascii ascii",
+		&iso88592:    "iso-8859-2 iso8859-2 iso_8859-2 latin2 l2",
+		&iso88595:    "iso-8859-5 iso8859-5 iso_8859-5 cyrillic",
+		&iso885915:   "iso-8859-15 iso8859-15 iso_8859-15 latin9 latin-9",
+		&koi8r:       "koi8-r koi8r koi8 cskoi8r",
+		&koi8u:       "koi8-u koi8u",
+		&ibm866:      "ibm866 cp866 866 csibm866",
+		&kz1048:      "kz-1048 kz1048 rk1048 strk1048-2002",
+	} {
+		for _, label := range strings.Fields(labels) {
+			singleByteCharsets[label] = table
+		}
+	}
+}
+
+// xmlEncoding finds the encoding named by an XML declaration.
+var xmlEncoding = regexp.MustCompile(`^<\?xml[^>]*\sencoding\s*=\s*["']([^"']+)["']`)
+
+// charsetPeek is how much of the body is looked at to find its charset.
+const charsetPeek = 1024
+
+// utf8Reader returns body transcoded to UTF-8. The charset is taken from a
+// byte order mark, the charset parameter of contentType or the XML
+// declaration, which is the order RFC 7303 ranks them in. A source claiming
+// UTF-8 for a body that does not start as valid UTF-8 is passed over, since
+// many servers announce UTF-8 whatever they serve.
+func utf8Reader(contentType string, body io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(body, charsetPeek)
+	// a short body or a read error shows up again on the first real read
+	head, _ := br.Peek(charsetPeek)
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		br.Discard(len(utf8BOM))
+		return br, nil
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		br.Discard(2)
+		return newDecodingReader(br, decodeUTF16(false)), nil
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		br.Discard(2)
+		return newDecodingReader(br, decodeUTF16(true)), nil
+	}
+
+	var header string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		header = params["charset"]
+	}
+	var declared string
+	if m := xmlEncoding.FindSubmatch(bytes.TrimSpace(head)); m != nil {
+		declared = string(m[1])
+	}
+	for _, label := range []string{header, declared} {
+		label = strings.ToLower(strings.TrimSpace(label))
+		switch {
+		case label == "":
+		case label == "utf-8" || label == "utf8":
+			if validUTF8Prefix(head) {
+				return br, nil
+			}
+		case strings.HasPrefix(label, "utf-16"):
+			return newDecodingReader(br, decodeUTF16(utf16BigEndian(label, head))), nil
+		case singleByteCharsets[label] != nil:
+			return newDecodingReader(br, decodeSingleByte(singleByteCharsets[label])), nil
+		default:
+			return nil, fmt.Errorf("unsupported charset %q", label)
+		}
+	}
+	// a UTF-16 document may carry neither a byte order mark nor a label
+	// the decoder could read, but starts with a "<" either way
+	switch {
+	case bytes.HasPrefix(head, []byte{'<', 0}):
+		return newDecodingReader(br, decodeUTF16(false)), nil
+	case bytes.HasPrefix(head, []byte{0, '<'}):
+		return newDecodingReader(br, decodeUTF16(true)), nil
+	}
+	return br, nil
+}
+
+// validUTF8Prefix reports whether head is valid UTF-8 but for a character
+// cut off at its end.
+func validUTF8Prefix(head []byte) bool {
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRune(head[i:]) {
+				head = head[:i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(head)
+}
+
+// utf16BigEndian tells the byte order of UTF-16 text starting with head and
+// labelled label. An unmarked "utf-16" is little endian unless the text
+// starts like big endian text, as browsers assume.
+func utf16BigEndian(label string, head []byte) bool {
+	switch label {
+	case "utf-16be":
+		return true
+	case "utf-16le":
+		return false
+	}
+	return len(head) > 1 && head[0] == 0 && head[1] != 0
+}
+
+// decodeFunc appends the UTF-8 encoding of in to out and returns it with
+// the number of bytes of in used. Bytes of a character that continues past
+// the end of in are left for the next call.
+type decodeFunc func(out, in []byte) ([]byte, int)
+
+func decodeUTF16(bigEndian bool) decodeFunc {
+	unit := func(b []byte) rune {
+		if bigEndian {
+			return rune(b[0])<<8 | rune(b[1])
+		}
+		return rune(b[1])<<8 | rune(b[0])
+	}
+	return func(out, in []byte) ([]byte, int) {
+		i := 0
+		for i+1 < len(in) {
+			r := unit(in[i:])
+			if r < 0xD800 || r > 0xDBFF {
+				// lone low surrogates are invalid
+				if utf16.IsSurrogate(r) {
+					r = utf8.RuneError
+				}
+				out = utf8.AppendRune(out, r)
+				i += 2
+				continue
+			}
+			if i+3 >= len(in) {
+				break
+			}
+			if pair := utf16.DecodeRune(r, unit(in[i+2:])); pair != utf8.RuneError {
+				out = utf8.AppendRune(out, pair)
+				i += 4
+				continue
+			}
+			out = utf8.AppendRune(out, utf8.RuneError)
+			i += 2
+		}
+		return out, i
+	}
+}
+
+func decodeSingleByte(table *[128]rune) decodeFunc {
+	return func(out, in []byte) ([]byte, int) {
+		for _, b := range in {
+			if b < 0x80 {
+				out = append(out, b)
+			} else {
+				out = utf8.AppendRune(out, table[b-0x80])
+			}
+		}
+		return out, len(in)
+	}
+}
+
+// decodingReader transcodes what it reads from src to UTF-8.
+type decodingReader struct {
+	src    io.Reader
+	decode decodeFunc
+	buf    []byte
+	// pending is the number of bytes at the start of buf left undecoded
+	pending int
+	// out is the part of dst not read yet
+	dst, out []byte
+	err      error
+}
+
+func newDecodingReader(src io.Reader, decode decodeFunc) *decodingReader {
+	return &decodingReader{src: src, decode: decode, buf: make([]byte, 4096)}
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			if d.pending == 0 {
+				return 0, d.err
+			}
+			// a character cut off by the end of the input
+			d.dst = utf8.AppendRune(d.dst[:0], utf8.RuneError)
+			d.out = d.dst
+			d.pending = 0
+			break
+		}
+		n, err := d.src.Read(d.buf[d.pending:])
+		d.err = err
+		var used int
+		d.dst, used = d.decode(d.dst[:0], d.buf[:d.pending+n])
+		d.out = d.dst
+		d.pending = copy(d.buf, d.buf[used:d.pending+n])
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// newXMLDecoder returns a decoder for a document utf8Reader has already
+// transcoded. Its XML declaration may still name the original charset,
+// which the decoder refuses without a CharsetReader, so the one set here
+// passes the text through unchanged.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return dec
+}
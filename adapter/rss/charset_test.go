@@ -0,0 +1,54 @@
+package rss
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseDecodesCharset(t *testing.T) {
+	const title = "Новости"
+	feed := func(encoding string) string {
+		return `<?xml version="1.0" encoding="` + encoding + `"?><rss version="2.0"><channel><item><title>` + title + `</title></item></channel></rss>`
+	}
+	encode := func(table *[128]rune, s string) []byte {
+		var out []byte
+		for _, r := range s {
+			if r < 0x80 {
+				out = append(out, byte(r))
+				continue
+			}
+			for i, x := range table {
+				if x == r {
+					out = append(out, byte(0x80+i))
+				}
+			}
+		}
+		return out
+	}
+	utf16LE := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(feed("utf-16"))) {
+		utf16LE = append(utf16LE, byte(u), byte(u>>8))
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"declaration", "text/xml", encode(&windows1251, feed("windows-1251"))},
+		{"header", "text/xml; charset=KOI8-R", encode(&koi8r, feed("koi8-r"))},
+		{"wrong utf-8 header", "text/xml; charset=utf-8", encode(&windows1251, feed("windows-1251"))},
+		{"bom", "text/xml", utf16LE},
+	}
+	for _, tt := range tests {
+		res, err := parse(tt.contentType, bytes.NewReader(tt.body), Limits{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(res.Items) != 1 || res.Items[0].Title != title {
+			t.Errorf("%s: got %+v, want one item titled %q", tt.name, res.Items, title)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// isoLayouts covers the W3C-DTF profile of ISO 8601 used by Atom, Dublin
+// Core dc:date and JSON Feed, and the variants of it seen in RSS. Dates
+// without a zone are taken as UTC.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses the publication dates found in feeds: ISO 8601 and the
+// RFC 822 family with the liberties publishers take with it, such as
+// two-digit years, missing weekdays, "GMT+2" offsets, named zones and
+// month names in other languages. It reports whether value held a usable
+// date.
+func parseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, ok := parseTime(value, isoLayouts); ok {
+		return t, true
+	}
+	return parseLooseDate(value)
+}
+
+// parseTime tries each layout in turn and reports whether any matched.
+func parseTime(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// looseDate collects the parts of a date as parseLooseDate finds them.
+type looseDate struct {
+	year, month, day     int
+	hour, min, sec, nsec int
+	hasTime, pm, am      bool
+	zone                 string
+	offset               int
+	hasZone              bool
+}
+
+// parseLooseDate reads the fields of value in any order, recognising them
+// by their shape. Words it does not know, like weekdays or the "de" of
+// "5 de mayo de 2024", are skipped.
+func parseLooseDate(value string) (time.Time, bool) {
+	// drop comments such as the "(CEST)" of JavaScript date strings
+	for {
+		open := strings.IndexByte(value, '(')
+		end := strings.IndexByte(value, ')')
+		if open < 0 || end < open {
+			break
+		}
+		value = value[:open] + " " + value[end+1:]
+	}
+	// a word before the first comma is the weekday; dropping it keeps the
+	// French "mar." (Tuesday) from being read as March
+	if head, rest, ok := strings.Cut(value, ","); ok && isWord(strings.TrimSuffix(strings.TrimSpace(head), ".")) {
+		value = rest
+	}
+
+	var d looseDate
+	for _, tok := range strings.FieldsFunc(value, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		if !d.token(tok) {
+			return time.Time{}, false
+		}
+	}
+	return d.time()
+}
+
+// token records tok and reports whether it made sense.
+func (d *looseDate) token(tok string) bool {
+	// an empty part of a dashed date such as "05--2024"
+	if tok == "" {
+		return false
+	}
+	if tok[0] == '+' || tok[0] == '-' {
+		return d.setOffset(tok)
+	}
+	if y, m, dd, clock, ok := isoDate(tok); ok {
+		d.year, d.month, d.day = y, m, dd
+		return clock == "" || d.setClock(clock)
+	}
+	if name, off, ok := strings.Cut(tok, "+"); ok && isUTCName(name) {
+		return d.setOffset("+" + off)
+	}
+	if name, off, ok := strings.Cut(tok, "-"); ok && isUTCName(name) {
+		return d.setOffset("-" + off)
+	}
+	if strings.ContainsRune(tok, ':') {
+		return d.setClock(tok)
+	}
+	// 02-Jan-2006 and the European 02.01.2006
+	for _, sep := range []string{"-", ".", "/"} {
+		parts := strings.Split(strings.TrimSuffix(tok, sep), sep)
+		if len(parts) != 3 {
+			continue
+		}
+		if sep != "-" {
+			// numeric dates with slashes are month first in the US and day
+			// first elsewhere, so only dotted ones are trusted
+			if sep == "/" {
+				return false
+			}
+			dd, err1 := strconv.Atoi(parts[0])
+			m, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			d.day, d.month = dd, m
+			return d.setNumber(parts[2])
+		}
+		for _, p := range parts {
+			if !d.token(p) {
+				return false
+			}
+		}
+		return true
+	}
+	if unicode.IsDigit(rune(tok[0])) {
+		return d.setNumber(tok)
+	}
+	return d.setWord(tok)
+}
+
+func (d *looseDate) setNumber(tok string) bool {
+	// ordinals such as 1st, 2nd or the French 1er
+	digits := strings.TrimRightFunc(tok, func(r rune) bool { return !unicode.IsDigit(r) })
+	if !isWord(strings.TrimSuffix(tok[len(digits):], ".")) && len(digits) != len(tok) {
+		return false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return false
+	}
+	switch {
+	case len(digits) == 4 && d.year == 0:
+		d.year = n
+	case len(digits) <= 2 && d.day == 0:
+		d.day = n
+	case len(digits) <= 2 && d.year == 0:
+		d.year = twoDigitYear(n)
+	default:
+		return false
+	}
+	return true
+}
+
+func (d *looseDate) setWord(tok string) bool {
+	if off, ok := zoneOffsets[tok]; ok {
+		d.zone, d.offset, d.hasZone = tok, off, true
+		return true
+	}
+	word := strings.ToLower(strings.TrimSuffix(tok, "."))
+	if m, ok := monthNames[word]; ok {
+		// a later month name wins; an earlier one was a weekday that
+		// shares its abbreviation
+		d.month = int(m)
+		return true
+	}
+	switch strings.ReplaceAll(word, ".", "") {
+	case "am":
+		d.am = true
+	case "pm":
+		d.pm = true
+	}
+	return true
+}
+
+// setClock parses 15:04, 15:04:05 or 15:04:05.999 with an optional zone
+// suffix such as Z or +02:00.
+func (d *looseDate) setClock(tok string) bool {
+	if i := strings.IndexAny(tok, "+-Z"); i > 0 {
+		if tok[i] == 'Z' {
+			if tok[i:] != "Z" {
+				return false
+			}
+			d.hasZone = true
+		} else if !d.setOffset(tok[i:]) {
+			return false
+		}
+		tok = tok[:i]
+	}
+	parts := strings.Split(tok, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	var err error
+	if d.hour, err = strconv.Atoi(parts[0]); err != nil {
+		return false
+	}
+	if d.min, err = strconv.Atoi(parts[1]); err != nil {
+		return false
+	}
+	if len(parts) == 3 {
+		sec, frac, _ := strings.Cut(parts[2], ".")
+		if d.sec, err = strconv.Atoi(sec); err != nil {
+			return false
+		}
+		if frac != "" {
+			if len(frac) > 9 {
+				frac = frac[:9]
+			}
+			ns, err := strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
+			if err != nil {
+				return false
+			}
+			d.nsec = ns
+		}
+	}
+	d.hasTime = true
+	return d.hour < 24 && d.min < 60 && d.sec < 61
+}
+
+// setOffset parses a numeric zone offset: +0200, +02:00, +02, +2 or +530.
+func (d *looseDate) setOffset(tok string) bool {
+	sign := 1
+	if tok[0] == '-' {
+		sign = -1
+	}
+	tok = tok[1:]
+	var hours, mins string
+	switch h, m, ok := strings.Cut(tok, ":"); {
+	case ok:
+		hours, mins = h, m
+	case len(tok) <= 2:
+		hours = tok
+	case len(tok) <= 4:
+		hours, mins = tok[:len(tok)-2], tok[len(tok)-2:]
+	default:
+		return false
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil || h > 14 {
+		return false
+	}
+	m := 0
+	if mins != "" {
+		if m, err = strconv.Atoi(mins); err != nil || m > 59 {
+			return false
+		}
+	}
+	d.offset, d.hasZone = sign*(h*3600+m*60), true
+	// keep a named zone that the offset merely repeats, as in "GMT +0000"
+	if off, ok := zoneOffsets[d.zone]; !ok || off != d.offset {
+		d.zone = ""
+	}
+	return true
+}
+
+func (d *looseDate) time() (time.Time, bool) {
+	if d.year == 0 || d.month == 0 || d.day == 0 {
+		return time.Time{}, false
+	}
+	hour := d.hour
+	switch {
+	case d.pm && hour < 12:
+		hour += 12
+	case d.am && hour == 12:
+		hour = 0
+	}
+	loc := time.UTC
+	if d.hasZone && (d.offset != 0 || d.zone != "") {
+		loc = time.FixedZone(d.zone, d.offset)
+	}
+	t := time.Date(d.year, time.Month(d.month), d.day, hour, d.min, d.sec, d.nsec, loc)
+	// time.Date normalises 31 April to 1 May; such dates are garbage
+	if t.Day() != d.day || int(t.Month()) != d.month {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// isoDate splits 2006-01-02 or 2006-01-02T15:04:05... into the date and
+// the clock that follows the T.
+func isoDate(tok string) (year, month, day int, clock string, ok bool) {
+	date, clock, _ := strings.Cut(tok, "T")
+	parts := strings.Split(date, "-")
+	if len(parts) != 3 || len(parts[0]) != 4 {
+		return 0, 0, 0, "", false
+	}
+	var err [3]error
+	year, err[0] = strconv.Atoi(parts[0])
+	month, err[1] = strconv.Atoi(parts[1])
+	day, err[2] = strconv.Atoi(parts[2])
+	if err[0] != nil || err[1] != nil || err[2] != nil {
+		return 0, 0, 0, "", false
+	}
+	return year, month, day, clock, true
+}
+
+// twoDigitYear follows time.Parse: 69-99 are the 1900s, 00-68 the 2000s.
+func twoDigitYear(n int) int {
+	if n >= 69 {
+		return 1900 + n
+	}
+	return 2000 + n
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUTCName(s string) bool {
+	return s == "GMT" || s == "UTC" || s == "UT"
+}
+
+// zoneOffsets maps the zone names seen in feeds to their offsets in
+// seconds. Names are only matched in upper case, so words like the French
+// "est" are not taken for a zone.
+var zoneOffsets = map[string]int{
+	"UT": 0, "UTC": 0, "GMT": 0, "Z": 0, "WET": 0,
+	"EST": -5 * 3600, "EDT": -4 * 3600,
+	"CST": -6 * 3600, "CDT": -5 * 3600,
+	"MST": -7 * 3600, "MDT": -6 * 3600,
+	"PST": -8 * 3600, "PDT": -7 * 3600,
+	"AKST": -9 * 3600, "AKDT": -8 * 3600,
+	"HST": -10 * 3600,
+	"BST": 1 * 3600, "WEST": 1 * 3600,
+	"CET": 1 * 3600, "CEST": 2 * 3600, "MEZ": 1 * 3600, "MESZ": 2 * 3600,
+	"EET": 2 * 3600, "EEST": 3 * 3600,
+	"MSK": 3 * 3600,
+	"IST": 5*3600 + 1800,
+	"JST": 9 * 3600, "KST": 9 * 3600,
+	"AWST": 8 * 3600, "ACST": 9*3600 + 1800, "AEST": 10 * 3600, "AEDT": 11 * 3600,
+	"NZST": 12 * 3600, "NZDT": 13 * 3600,
+}
+
+// monthNames maps lower-case month names and their usual abbreviations to
+// months, in English and the languages feeds commonly localise dates into.
+var monthNames = map[string]time.Month{}
+
+func init() {
+	for m, names := range map[time.Month]string{
+		time.January: "jan january " +
+			"januar jän " + // German
+			"janvier janv " + // French
+			"enero ene " + // Spanish
+			"gennaio gen " + // Italian
+			"janeiro " + // Portuguese
+			"januari " + // Dutch
+			"января январь янв " + // Russian
+			"stycznia styczeń sty", // Polish
+		time.February: "feb february februar février févr fevrier fevr febrero febbraio fevereiro fev februari " +
+			"февраля февраль фев лютого luty lut",
+		time.March: "mar march märz mär mrz mars marzo março marco maart mrt марта март мар marca marzec",
+		time.April: "apr april avril avr abril abr aprile апреля апрель апр kwietnia kwiecień kwi",
+		time.May:   "may mai mayo maggio mag maio mei мая май maja maj",
+		time.June:  "jun june juni juin junio giugno giu junho июня июнь июн czerwca czerwiec cze",
+		time.July:  "jul july juli juillet juil julio luglio lug julho июля июль июл lipca lipiec lip",
+		time.August: "aug august août aout agosto ago augustus августа август авг " +
+			"sierpnia sierpień sie",
+		time.September: "sep sept september septembre septiembre setiembre settembre set setembro " +
+			"сентября сентябрь сен сент września wrzesień wrz",
+		time.October: "oct october oktober okt octobre octubre ottobre ott outubro out " +
+			"октября октябрь окт października październik paź",
+		time.November: "nov november novembre noviembre novembro ноября ноябрь ноя listopada listopad lis",
+		time.December: "dec december dezember dez décembre déc diciembre dic dicembre dezembro " +
+			"декабря декабрь дек grudnia grudzień gru",
+	} {
+		for _, name := range strings.Fields(names) {
+			monthNames[name] = m
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	cet := time.FixedZone("", 3600)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"Tue, 05 Mar 2024 10:00:00 +0100", time.Date(2024, 3, 5, 10, 0, 0, 0, cet)},
+		{"Tue, 05 Mar 24 10:00:00 EST", time.Date(2024, 3, 5, 10, 0, 0, 0, time.FixedZone("EST", -5*3600))},
+		{"Tue, 5 Mar 2024 10:00:00 GMT+2", time.Date(2024, 3, 5, 10, 0, 0, 0, time.FixedZone("", 2*3600))},
+		{"2024-03-05T10:00:00.5+01:00", time.Date(2024, 3, 5, 10, 0, 0, 5e8, cet)},
+		{"2024-03-05 10:00:00", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{"Di, 05 Mär 2024 10:00:00 +0100", time.Date(2024, 3, 5, 10, 0, 0, 0, cet)},
+		{"mar., 05 mars 2024 10:00:00 +0100", time.Date(2024, 3, 5, 10, 0, 0, 0, cet)},
+		{"5 de marzo de 2024 10:00", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{"5 марта 2024 г. 10:00", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{"05.03.2024", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"March 5, 2024 3:04 PM", time.Date(2024, 3, 5, 15, 4, 0, 0, time.UTC)},
+		{"Tue Mar 05 2024 10:00:00 GMT+0100 (Central European Standard Time)", time.Date(2024, 3, 5, 10, 0, 0, 0, cet)},
+	}
+	for _, tt := range tests {
+		got, ok := parseDate(tt.in)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, %v; want %v", tt.in, got, ok, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "yesterday", "31 Apr 2024", "03/05/2024", "05--2024", "--"} {
+		if got, ok := parseDate(in); ok {
+			t.Errorf("parseDate(%q) = %v, want no date", in, got)
+		}
+	}
+}
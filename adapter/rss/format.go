@@ -0,0 +1,97 @@
+package rss
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"rsshub/domain"
+	"strings"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// parse reads a feed from body, transcoding it to UTF-8 and handing it to
+// the parser of its format as a stream, so that no more of it is held in
+// memory than the items kept within lim. Only Items, Hints and Truncated
+// of the result are filled in. A body cut off at the size limit is not an
+// error; the items before the cut are returned.
+func parse(contentType string, body io.Reader, lim Limits) (domain.FetchResult, error) {
+	r, err := utf8Reader(contentType, body)
+	if err != nil {
+		return domain.FetchResult{}, err
+	}
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("%w (content type %q)", domain.ErrUnsupportedFormat, contentType)
+		}
+		return domain.FetchResult{}, err
+	}
+
+	list := &itemList{lim: lim}
+	var hints domain.ScheduleHints
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	// publishers frequently serve XML feeds as text/html or
+	// application/octet-stream, so the header is only trusted to
+	// announce JSON
+	case first == '{' || strings.HasSuffix(mediaType, "json"):
+		err = parseJSONFeed(br, list)
+	case first == '<':
+		hints, err = parseXML(br, list, contentType)
+	default:
+		return domain.FetchResult{}, fmt.Errorf("%w (content type %q)", domain.ErrUnsupportedFormat, contentType)
+	}
+	if errors.Is(err, errBodyTooLarge) {
+		list.cut.Body = true
+		err = nil
+	}
+	if err != nil {
+		return domain.FetchResult{}, err
+	}
+	return domain.FetchResult{Items: list.items, Hints: hints, Truncated: list.cut}, nil
+}
+
+// parseXML finds the root element of an XML feed, which tells its format,
+// and decodes the document with the matching parser.
+func parseXML(r io.Reader, list *itemList, contentType string) (domain.ScheduleHints, error) {
+	dec := newXMLDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return domain.ScheduleHints{}, err
+		}
+		root, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(root.Name.Local) {
+		case "rss":
+			return parseRSS(dec, &root, list)
+		case "feed":
+			return parseAtom(dec, &root, list)
+		case "rdf":
+			return parseRDF(dec, &root, list)
+		default:
+			return domain.ScheduleHints{}, fmt.Errorf("%w (content type %q)", domain.ErrUnsupportedFormat, contentType)
+		}
+	}
+}
+
+// firstByte returns the first byte of r that is not white space, leaving it
+// unread.
+func firstByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package rss
+
+import (
+	"errors"
+	"rsshub/domain"
+	"strings"
+	"testing"
+)
+
+func TestParseDetectsFormat(t *testing.T) {
+	const (
+		rssDoc  = `<rss version="2.0"><channel><item><title>Item</title><link>https://example.com/1</link></item></channel></rss>`
+		atomDoc = `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>urn:1</id><title>Item</title></entry></feed>`
+		rdfDoc  = `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"><channel/><item rdf:about="urn:1"><title>Item</title></item></rdf:RDF>`
+		jsonDoc = `{"version":"https://jsonfeed.org/version/1.1","items":[{"id":"1","title":"Item"}]}`
+	)
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		unsupported bool
+	}{
+		{"rss", "application/rss+xml", rssDoc, false},
+		{"atom", "application/atom+xml", atomDoc, false},
+		{"rdf", "application/rdf+xml", rdfDoc, false},
+		{"json", "application/feed+json", jsonDoc, false},
+		{"xml served as html", "text/html", rssDoc, false},
+		{"json served as octet stream", "application/octet-stream", jsonDoc, false},
+		{"no content type", "", atomDoc, false},
+		{"declaration and comment", "text/xml", `<?xml version="1.0"?>` + "\n<!-- generated -->\n" + rssDoc, false},
+		{"bom and white space", "text/xml", "\xEF\xBB\xBF \r\n\t" + rssDoc, false},
+		{"leading white space json", "", "\n  " + jsonDoc, false},
+		{"upper case root", "text/xml", `<RSS version="2.0"><channel><item><title>Item</title></item></channel></RSS>`, false},
+		{"html page", "text/html", `<!DOCTYPE html><html><body>Not a feed</body></html>`, true},
+		{"unknown root", "text/xml", `<?xml version="1.0"?><sitemap/>`, true},
+		{"plain text", "text/plain", "Not a feed", true},
+		{"empty", "text/xml", " \n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parse(tt.contentType, strings.NewReader(tt.body), Limits{})
+			if tt.unsupported {
+				if !errors.Is(err, domain.ErrUnsupportedFormat) {
+					t.Fatalf("got %d items, err %v; want %v", len(res.Items), err, domain.ErrUnsupportedFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != 1 || res.Items[0].Title != "Item" {
+				t.Fatalf("got %+v", res.Items)
+			}
+		})
+	}
+}
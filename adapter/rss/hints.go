@@ -0,0 +1,91 @@
+package rss
+
+import (
+	"rsshub/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHintInterval caps publisher-declared intervals; a feed asking to be
+// polled yearly is still checked weekly.
+const maxHintInterval = 7 * 24 * time.Hour
+
+// syndication holds the RSS syndication module elements
+// (http://purl.org/rss/1.0/modules/syndication/).
+type syndication struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+// interval converts sy:updatePeriod and sy:updateFrequency (updates per
+// period, default 1) to a polling interval, 0 if not declared.
+func (s syndication) interval() time.Duration {
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(s.UpdatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+	freq, err := strconv.Atoi(strings.TrimSpace(s.UpdateFrequency))
+	if err != nil || freq < 1 {
+		freq = 1
+	}
+	return min(period/time.Duration(freq), maxHintInterval)
+}
+
+// ttlInterval converts an RSS <ttl> value in minutes.
+func ttlInterval(ttl string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err != nil || minutes <= 0 {
+		return 0
+	}
+	return min(time.Duration(minutes)*time.Minute, maxHintInterval)
+}
+
+// skipHoursMask turns <skipHours><hour> values (0-23, GMT) into a bit mask.
+// Some publishers write 24 for midnight.
+func skipHoursMask(hours []string) uint32 {
+	var mask uint32
+	for _, h := range hours {
+		n, err := strconv.Atoi(strings.TrimSpace(h))
+		if err != nil || n < 0 || n > 24 {
+			continue
+		}
+		mask |= 1 << (n % 24)
+	}
+	return mask
+}
+
+// skipDaysMask turns <skipDays><day> names into a bit mask indexed by
+// time.Weekday.
+func skipDaysMask(days []string) uint8 {
+	var mask uint8
+	for _, d := range days {
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if strings.EqualFold(strings.TrimSpace(d), wd.String()) {
+				mask |= 1 << wd
+			}
+		}
+	}
+	return mask
+}
+
+// hintsFrom assembles the schedule hints declared by a feed.
+func hintsFrom(ttl string, sy syndication, skipHours, skipDays []string) domain.ScheduleHints {
+	return domain.ScheduleHints{
+		TTL:          ttlInterval(ttl),
+		UpdatePeriod: sy.interval(),
+		SkipHours:    skipHoursMask(skipHours),
+		SkipDays:     skipDaysMask(skipDays),
+	}
+}
@@ -0,0 +1,35 @@
+package rss
+
+import (
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+func TestHintsFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       string
+		sy        syndication
+		skipHours []string
+		skipDays  []string
+		want      domain.ScheduleHints
+	}{
+		{"none", "", syndication{}, nil, nil, domain.ScheduleHints{}},
+		{"ttl", " 90 ", syndication{}, nil, nil, domain.ScheduleHints{TTL: 90 * time.Minute}},
+		{"bad ttl", "soon", syndication{}, nil, nil, domain.ScheduleHints{}},
+		{"ttl capped", "20160", syndication{}, nil, nil, domain.ScheduleHints{TTL: maxHintInterval}},
+		{"hourly", "", syndication{UpdatePeriod: "hourly"}, nil, nil, domain.ScheduleHints{UpdatePeriod: time.Hour}},
+		{"twice daily", "", syndication{UpdatePeriod: " Daily", UpdateFrequency: "2"}, nil, nil, domain.ScheduleHints{UpdatePeriod: 12 * time.Hour}},
+		{"bad frequency", "", syndication{UpdatePeriod: "daily", UpdateFrequency: "0"}, nil, nil, domain.ScheduleHints{UpdatePeriod: 24 * time.Hour}},
+		{"frequency without period", "", syndication{UpdateFrequency: "4"}, nil, nil, domain.ScheduleHints{}},
+		{"yearly capped", "", syndication{UpdatePeriod: "yearly"}, nil, nil, domain.ScheduleHints{UpdatePeriod: maxHintInterval}},
+		{"skip hours", "", syndication{}, []string{"0", " 13", "24", "25", "x"}, nil, domain.ScheduleHints{SkipHours: 1<<0 | 1<<13}},
+		{"skip days", "", syndication{}, nil, []string{"saturday", "Sunday ", "Someday"}, domain.ScheduleHints{SkipDays: 1<<time.Saturday | 1<<time.Sunday}},
+	}
+	for _, tt := range tests {
+		if got := hintsFrom(tt.ttl, tt.sy, tt.skipHours, tt.skipDays); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
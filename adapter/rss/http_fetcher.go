@@ -2,76 +2,144 @@ package rss
 
 import (
 	"context"
-	"encoding/xml"
-	"io"
+	"errors"
+	"log/slog"
 	"net/http"
 	"rsshub/domain"
 	"time"
 )
 
-type HTTPFetcher struct{ client *http.Client }
+type HTTPFetcher struct {
+	client *http.Client
+	limits Limits
+	log    *slog.Logger
+}
 
 func NewHTTPFetcher() *HTTPFetcher {
-	return &HTTPFetcher{client: &http.Client{Timeout: 20 * time.Second}}
+	return &HTTPFetcher{client: &http.Client{Timeout: 20 * time.Second, CheckRedirect: checkRedirect}, limits: DefaultLimits, log: slog.Default()}
+}
+
+// WithLimits replaces the DefaultLimits of what a fetch reads and keeps.
+func (f *HTTPFetcher) WithLimits(l Limits) *HTTPFetcher {
+	f.limits = l
+	return f
 }
 
-func (f *HTTPFetcher) Fetch(ctx context.Context, feedURL string) ([]domain.FetchedItem, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+// WithLogger makes f log requests and responses to l at debug level.
+func (f *HTTPFetcher) WithLogger(l *slog.Logger) *HTTPFetcher {
+	f.log = l
+	return f
+}
+
+// Fetch downloads and parses feed. When the feed carries validators from a
+// previous fetch the request is made conditional, and a 304 answer is
+// reported through FetchResult.NotModified without reading the body.
+// Non-success statuses and unparseable payloads are returned as
+// *domain.FetchError. The body is parsed as it streams in; whatever is cut
+// to stay within the fetcher's limits is reported in
+// FetchResult.Truncated, and a document cut short or missing items comes
+// without validators, so the next fetch downloads it in full again.
+func (f *HTTPFetcher) Fetch(ctx context.Context, feed domain.Feed) (domain.FetchResult, error) {
+	log := f.log.With("feed_id", feed.ID, "feed", feed.Name)
+	trace := &redirectTrace{permanent: true, log: log}
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, redirectTraceKey{}, trace), http.MethodGet, feed.URL, nil)
 	if err != nil {
-		return nil, err
+		return domain.FetchResult{}, err
+	}
+	if feed.Validators.ETag != "" {
+		req.Header.Set("If-None-Match", feed.Validators.ETag)
 	}
+	if feed.Validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", feed.Validators.LastModified)
+	}
+	log.Debug("fetching feed", "url", feed.URL, "if_none_match", feed.Validators.ETag, "if_modified_since", feed.Validators.LastModified)
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return nil, err
+		return domain.FetchResult{}, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, nil
+	log.Debug("feed response", "status", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"), "etag", resp.Header.Get("ETag"))
+
+	res := domain.FetchResult{StatusCode: resp.StatusCode, MovedTo: trace.movedTo()}
+	if resp.StatusCode == http.StatusNotModified {
+		res.NotModified = true
+		res.Validators = notModifiedValidators(resp.Header, feed.Validators)
+		return res, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return res, statusError(resp)
+	}
+	body := &limitedBody{r: resp.Body, max: f.limits.MaxBodyBytes}
+	parsed, err := parse(resp.Header.Get("Content-Type"), body, f.limits)
+	res.Bytes = body.bytes()
+	// the connection failed, not the document
+	if body.err != nil {
+		return res, body.err
 	}
-	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return res, &domain.FetchError{Kind: domain.ErrParse, Err: err}
+	}
+	log.Debug("parsed feed", "bytes", res.Bytes, "items", len(parsed.Items), "truncated", parsed.Truncated.String())
+	res.Items = parsed.Items
+	res.Hints = parsed.Hints
+	res.Truncated = parsed.Truncated
+	// a 304 to the validators of a cut document would never bring the
+	// items past the cut
+	if !res.Truncated.Body && res.Truncated.ItemsDropped == 0 {
+		res.Validators = responseValidators(resp.Header)
 	}
-	var rf rssFeed
-	if err := xml.Unmarshal(bytes, &rf); err != nil {
-		return nil, err
+	return res, nil
+}
+
+func responseValidators(h http.Header) domain.CacheValidators {
+	return domain.CacheValidators{ETag: h.Get("ETag"), LastModified: h.Get("Last-Modified")}
+}
+
+// notModifiedValidators returns the validators to keep after a 304. The
+// response may legitimately omit them, so the previously stored value is
+// kept for any header that is missing.
+func notModifiedValidators(h http.Header, prev domain.CacheValidators) domain.CacheValidators {
+	v := responseValidators(h)
+	if v.ETag == "" {
+		v.ETag = prev.ETag
 	}
-	items := make([]domain.FetchedItem, 0, len(rf.Channel.Item))
-	for _, it := range rf.Channel.Item {
-		var published time.Time
-		if it.PubDate != "" {
-			if p, perr := time.Parse(time.RFC1123Z, it.PubDate); perr == nil {
-				published = p
-			} else if p2, perr2 := time.Parse(time.RFC1123, it.PubDate); perr2 == nil {
-				published = p2
-			} else {
-				published = time.Now()
-			}
-		} else {
-			published = time.Now()
-		}
-		items = append(items, domain.FetchedItem{
-			Title:       it.Title,
-			Link:        it.Link,
-			Description: it.Description,
-			PublishedAt: published,
-		})
+	if v.LastModified == "" {
+		v.LastModified = prev.LastModified
 	}
-	return items, nil
+	return v
 }
 
-type rssFeed struct {
-	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []rssItem `xml:"item"`
-	} `xml:"channel"`
+type redirectTraceKey struct{}
+
+// redirectTrace records the redirect chain of a single request. A feed has
+// only moved permanently if every hop was a 301 or 308.
+type redirectTrace struct {
+	permanent bool
+	url       string
+	log       *slog.Logger
 }
 
-type rssItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+func (t *redirectTrace) movedTo() string {
+	if !t.permanent {
+		return ""
+	}
+	return t.url
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	trace, ok := req.Context().Value(redirectTraceKey{}).(*redirectTrace)
+	if !ok || req.Response == nil {
+		return nil
+	}
+	trace.log.Debug("following redirect", "status", req.Response.StatusCode, "to", req.URL.String())
+	switch req.Response.StatusCode {
+	case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		trace.url = req.URL.String()
+	default:
+		trace.permanent = false
+	}
+	return nil
 }
@@ -0,0 +1,170 @@
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"rsshub/domain"
+	"strings"
+	"time"
+)
+
+type jsonFeedItem struct {
+	ID            json.RawMessage `json:"id"`
+	URL           string          `json:"url"`
+	ExternalURL   string          `json:"external_url"`
+	Title         string          `json:"title"`
+	Summary       string          `json:"summary"`
+	ContentHTML   string          `json:"content_html"`
+	ContentText   string          `json:"content_text"`
+	DatePublished string          `json:"date_published"`
+	DateModified  string          `json:"date_modified"`
+	// Author is the single author of version 1.0, Authors the list that
+	// replaced it in 1.1.
+	Author      *jsonFeedAuthor      `json:"author"`
+	Authors     []jsonFeedAuthor     `json:"authors"`
+	Tags        []string             `json:"tags"`
+	Attachments []jsonFeedAttachment `json:"attachments"`
+}
+
+type jsonFeedAttachment struct {
+	URL      string  `json:"url"`
+	MIMEType string  `json:"mime_type"`
+	Size     int64   `json:"size_in_bytes"`
+	Duration float64 `json:"duration_in_seconds"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// parseJSONFeed reads a JSON Feed 1.0/1.1 document
+// (https://jsonfeed.org/version/1.1), walking its top-level object token by
+// token and decoding the entries of its items array one at a time.
+func parseJSONFeed(r io.Reader, list *itemList) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key != "items" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			if list.full() {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return err
+				}
+				list.cut.ItemsDropped++
+				continue
+			}
+			var it jsonFeedItem
+			if err := dec.Decode(&it); err != nil {
+				return err
+			}
+			list.add(it.item())
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v, found %v", want, tok)
+	}
+	return nil
+}
+
+func (it jsonFeedItem) item() domain.FetchedItem {
+	authors := it.Authors
+	if it.Author != nil {
+		authors = append(authors, *it.Author)
+	}
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		names = append(names, firstNonEmpty(a.Name, a.URL))
+	}
+	var files []domain.Enclosure
+	for _, a := range it.Attachments {
+		if url := strings.TrimSpace(a.URL); url != "" {
+			files = append(files, domain.Enclosure{
+				Kind:     domain.EnclosureFile,
+				URL:      url,
+				Type:     strings.TrimSpace(a.MIMEType),
+				Length:   max(a.Size, 0),
+				Duration: seconds(a.Duration),
+			})
+		}
+	}
+	return domain.FetchedItem{
+		GUID:        it.id(),
+		Title:       strings.TrimSpace(it.Title),
+		Link:        it.link(),
+		Description: firstNonEmpty(it.Summary, it.ContentHTML, it.ContentText),
+		Content:     firstNonEmpty(it.ContentHTML, it.ContentText),
+		PublishedAt: it.published(),
+		Authors:     names,
+		Categories:  it.Tags,
+		Enclosures:  files,
+	}
+}
+
+// link returns the item's permalink. Items may omit url, in which case the
+// external url or, failing that, the id is used as the article key.
+func (it jsonFeedItem) link() string {
+	if link := firstNonEmpty(it.URL, it.ExternalURL); link != "" {
+		return link
+	}
+	return it.id()
+}
+
+func (it jsonFeedItem) id() string {
+	if len(it.ID) == 0 {
+		return ""
+	}
+	// 1.0 feeds in the wild sometimes publish numeric ids.
+	var id string
+	if err := json.Unmarshal(it.ID, &id); err == nil {
+		return strings.TrimSpace(id)
+	}
+	return strings.TrimSpace(string(it.ID))
+}
+
+func (it jsonFeedItem) published() time.Time {
+	for _, s := range []string{it.DatePublished, it.DateModified} {
+		if p, ok := parseDate(s); ok {
+			return p
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSONFeed(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	modified := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		item        string
+		guid        string
+		link        string
+		description string
+		content     string
+		published   time.Time
+	}{
+		{"html over text",
+			`"id":"1","url":"https://example.com/1","content_html":"<p>Hi</p>","content_text":"Hi"`,
+			"1", "https://example.com/1", "<p>Hi</p>", "<p>Hi</p>", time.Time{}},
+		{"text only",
+			`"id":"1","url":"https://example.com/1","content_text":"Hi"`,
+			"1", "https://example.com/1", "Hi", "Hi", time.Time{}},
+		{"summary as description",
+			`"id":"1","url":"https://example.com/1","summary":"Short","content_text":"Long"`,
+			"1", "https://example.com/1", "Short", "Long", time.Time{}},
+		{"date published",
+			`"id":"1","url":"https://example.com/1","date_published":"2024-05-01T14:00:00+02:00","date_modified":"2024-05-02T08:30:00Z"`,
+			"1", "https://example.com/1", "", "", published},
+		{"date modified only",
+			`"id":"1","url":"https://example.com/1","date_modified":"2024-05-02T08:30:00Z"`,
+			"1", "https://example.com/1", "", "", modified},
+		{"numeric id without url",
+			`"id":42,"external_url":"https://other.example.com/42"`,
+			"42", "https://other.example.com/42", "", "", time.Time{}},
+		{"id as link", `"id":"urn:1"`, "urn:1", "urn:1", "", "", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `{"version":"https://jsonfeed.org/version/1.1","title":"Feed","items":[{"title":"Item",` + tt.item + `}]}`
+			res, err := parse("application/feed+json", strings.NewReader(doc), Limits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != 1 {
+				t.Fatalf("got %d items", len(res.Items))
+			}
+			it := res.Items[0]
+			if it.GUID != tt.guid || it.Link != tt.link || it.Title != "Item" {
+				t.Errorf("guid %q, link %q, title %q; want %q, %q", it.GUID, it.Link, it.Title, tt.guid, tt.link)
+			}
+			if it.Description != tt.description || it.Content != tt.content {
+				t.Errorf("description %q, content %q; want %q, %q", it.Description, it.Content, tt.description, tt.content)
+			}
+			if !it.PublishedAt.Equal(tt.published) {
+				t.Errorf("published %v, want %v", it.PublishedAt, tt.published)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package rss
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"rsshub/domain"
+	"unicode/utf8"
+)
+
+// Limits bound what a single fetch reads and keeps. A zero field means no
+// limit.
+type Limits struct {
+	// MaxBodyBytes caps the response body after any content decoding, so
+	// a compressed bomb is cut off like a plain oversized body.
+	MaxBodyBytes int64
+	// MaxItems caps the items kept from one fetch; the ones after it are
+	// dropped.
+	MaxItems int
+	// MaxTitleBytes, MaxLinkBytes and MaxDescriptionBytes cap the fields
+	// of an item; longer values are cut at a character boundary. Authors
+	// and categories are capped like titles, GUIDs, comment links and
+	// enclosure URLs like links, and the content like the description.
+	MaxTitleBytes       int
+	MaxLinkBytes        int
+	MaxDescriptionBytes int
+}
+
+// DefaultLimits are the limits of a fetcher not given any.
+var DefaultLimits = Limits{
+	MaxBodyBytes:        10 << 20,
+	MaxItems:            500,
+	MaxTitleBytes:       1 << 10,
+	MaxLinkBytes:        2 << 10,
+	MaxDescriptionBytes: 64 << 10,
+}
+
+// errBodyTooLarge is returned by limitedBody once the size limit is passed.
+var errBodyTooLarge = errors.New("body over size limit")
+
+// limitedBody reads at most max bytes of r and fails with errBodyTooLarge
+// on the byte after. Errors of r itself are kept in err, so they can be
+// told apart from the document being malformed.
+type limitedBody struct {
+	r   io.Reader
+	max int64
+	n   int64
+	err error
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.max > 0 {
+		if l.n > l.max {
+			return 0, errBodyTooLarge
+		}
+		// read one byte past the limit to tell a body of exactly max bytes
+		// from a longer one
+		if rest := l.max - l.n + 1; int64(len(p)) > rest {
+			p = p[:rest]
+		}
+	}
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	if l.max > 0 && l.n > l.max {
+		return n - 1, errBodyTooLarge
+	}
+	return n, err
+}
+
+// bytes returns the number of body bytes passed on.
+func (l *limitedBody) bytes() int64 {
+	if l.max > 0 && l.n > l.max {
+		return l.max
+	}
+	return l.n
+}
+
+// itemList collects the items of a feed as they are decoded, within the
+// fetch limits.
+type itemList struct {
+	lim   Limits
+	items []domain.FetchedItem
+	cut   domain.Truncation
+}
+
+func (l *itemList) full() bool {
+	return l.lim.MaxItems > 0 && len(l.items) >= l.lim.MaxItems
+}
+
+func (l *itemList) add(it domain.FetchedItem) {
+	it.Title = l.clip(it.Title, l.lim.MaxTitleBytes)
+	it.Link = l.clip(it.Link, l.lim.MaxLinkBytes)
+	it.Description = l.clip(it.Description, l.lim.MaxDescriptionBytes)
+	it.GUID = l.clip(it.GUID, l.lim.MaxLinkBytes)
+	it.Comments = l.clip(it.Comments, l.lim.MaxLinkBytes)
+	it.Content = l.clip(it.Content, l.lim.MaxDescriptionBytes)
+	for i := range it.Authors {
+		it.Authors[i] = l.clip(it.Authors[i], l.lim.MaxTitleBytes)
+	}
+	for i := range it.Categories {
+		it.Categories[i] = l.clip(it.Categories[i], l.lim.MaxTitleBytes)
+	}
+	for i := range it.Enclosures {
+		it.Enclosures[i].URL = l.clip(it.Enclosures[i].URL, l.lim.MaxLinkBytes)
+		it.Enclosures[i].Type = l.clip(it.Enclosures[i].Type, l.lim.MaxTitleBytes)
+	}
+	l.items = append(l.items, it)
+}
+
+func (l *itemList) clip(v string, max int) string {
+	if max <= 0 || len(v) <= max {
+		return v
+	}
+	l.cut.FieldsCut++
+	for max > 0 && !utf8.RuneStart(v[max]) {
+		max--
+	}
+	return v[:max]
+}
+
+// xmlItems is the type of the item field of a feed document. The decoder
+// hands it each item element in turn, so items are added to the list as
+// they are read and the ones over the limit are skipped undecoded.
+type xmlItems[T interface{ item() domain.FetchedItem }] struct {
+	*itemList
+}
+
+func (x xmlItems[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if x.full() {
+		x.cut.ItemsDropped++
+		return d.Skip()
+	}
+	var v T
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	x.add(v.item())
+	return nil
+}
@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"rsshub/domain"
+	"strings"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<rss version="2.0"><channel><ttl>60</ttl>`)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, `<item><title>Заголовок %d</title><link>https://example.com/%d</link></item>`, i, i)
+	}
+	b.WriteString(`</channel></rss>`)
+	doc := b.String()
+
+	res, err := parse("text/xml", strings.NewReader(doc), Limits{MaxItems: 10, MaxTitleBytes: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 10 || res.Truncated.ItemsDropped != 90 || res.Truncated.FieldsCut != 10 {
+		t.Fatalf("got %d items, truncated %+v; want 10 items, 90 dropped, 10 fields cut", len(res.Items), res.Truncated)
+	}
+	// cut at a character boundary
+	if res.Items[0].Title != "Заголов" {
+		t.Errorf("title cut to %q", res.Items[0].Title)
+	}
+
+	body := &limitedBody{r: strings.NewReader(doc), max: 2000}
+	res, err = parse("text/xml", body, Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated.Body || len(res.Items) == 0 || len(res.Items) == 100 || body.bytes() != 2000 {
+		t.Fatalf("got %d items, truncated %+v after %d bytes; want some items, body cut at 2000", len(res.Items), res.Truncated, body.bytes())
+	}
+	if res.Hints.TTL == 0 {
+		t.Error("lost the channel ttl read before the cut")
+	}
+
+	body = &limitedBody{r: strings.NewReader(doc), max: int64(len(doc))}
+	if res, err := parse("text/xml", body, Limits{}); err != nil || res.Truncated.Body || len(res.Items) != 100 {
+		t.Fatalf("body of exactly the limit: got %d items, truncated %+v, err %v", len(res.Items), res.Truncated, err)
+	}
+}
+
+func TestFetchTruncatedKeepsNoValidators(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<rss version="2.0"><channel>`)
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, `<item><title>%d</title><link>https://example.com/%d</link></item>`, i, i)
+	}
+	b.WriteString(`</channel></rss>`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 12:00:00 GMT")
+		fmt.Fprint(w, b.String())
+	}))
+	defer srv.Close()
+	feed := domain.Feed{ID: "f", Name: "feed", URL: srv.URL}
+
+	tests := []struct {
+		name      string
+		lim       Limits
+		truncated bool
+	}{
+		{"whole", Limits{}, false},
+		{"fields cut", Limits{MaxLinkBytes: 10}, false},
+		{"items dropped", Limits{MaxItems: 5}, true},
+		{"body cut", Limits{MaxBodyBytes: 500}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewHTTPFetcher().WithLimits(tt.lim).Fetch(context.Background(), feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored := res.Validators != (domain.CacheValidators{}); stored == tt.truncated {
+				t.Fatalf("truncated %+v, validators %+v", res.Truncated, res.Validators)
+			}
+		})
+	}
+}
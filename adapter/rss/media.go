@@ -0,0 +1,138 @@
+package rss
+
+import (
+	"rsshub/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mediaExtensions are the Media RSS (http://search.yahoo.com/mrss/) and
+// iTunes podcast elements of an item. It is embedded ahead of the item's
+// own fields: the decoder hands an element to the first field that matches
+// it, and fields without a namespace, such as title or Atom's content,
+// would take media:title, itunes:title or media:content as well.
+type mediaExtensions struct {
+	MediaContent     []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnail   []mediaURL     `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroup       []mediaGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaTitle       string         `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string         `xml:"http://search.yahoo.com/mrss/ description"`
+	ITunesTitle      string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesDuration   string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode    string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+}
+
+// mediaGroup holds alternative versions of the same media object.
+type mediaGroup struct {
+	Content   []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnail []mediaURL     `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type mediaURL struct {
+	URL string `xml:"url,attr"`
+}
+
+// enclosures returns files, the item's own enclosures, followed by its
+// Media RSS content and thumbnails. A media object repeating the URL of
+// an earlier one only fills in what the earlier one left out. The iTunes
+// duration and episode describe the episode, whose audio is the item's
+// own enclosure.
+func (m mediaExtensions) enclosures(files ...domain.Enclosure) []domain.Enclosure {
+	duration, episode := parseDuration(m.ITunesDuration), int(parseCount(m.ITunesEpisode))
+	for i := range files {
+		if files[i].Duration == 0 {
+			files[i].Duration = duration
+		}
+		files[i].Episode = episode
+	}
+
+	contents, thumbnails := m.MediaContent, m.MediaThumbnail
+	for _, g := range m.MediaGroup {
+		contents = append(contents, g.Content...)
+		thumbnails = append(thumbnails, g.Thumbnail...)
+	}
+	all := files
+	for _, c := range contents {
+		all = append(all, domain.Enclosure{
+			Kind:     domain.EnclosureMedia,
+			URL:      c.URL,
+			Type:     c.Type,
+			Length:   parseCount(c.FileSize),
+			Duration: parseDuration(c.Duration),
+		})
+	}
+	for _, t := range thumbnails {
+		all = append(all, domain.Enclosure{Kind: domain.EnclosureThumbnail, URL: t.URL})
+	}
+
+	var out []domain.Enclosure
+	seen := make(map[string]int, len(all))
+	for _, e := range all {
+		e.URL, e.Type = strings.TrimSpace(e.URL), strings.TrimSpace(e.Type)
+		if e.URL == "" {
+			continue
+		}
+		i, ok := seen[e.URL]
+		if !ok {
+			seen[e.URL] = len(out)
+			out = append(out, e)
+			continue
+		}
+		if out[i].Type == "" {
+			out[i].Type = e.Type
+		}
+		if out[i].Length == 0 {
+			out[i].Length = e.Length
+		}
+		if out[i].Duration == 0 {
+			out[i].Duration = e.Duration
+		}
+	}
+	return out
+}
+
+// parseDuration parses an itunes:duration or a media:content duration:
+// seconds, "MM:SS" or "HH:MM:SS". It returns 0 for anything else.
+func parseDuration(s string) time.Duration {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0
+	}
+	var secs float64
+	for _, p := range parts {
+		n, err := strconv.ParseFloat(p, 64)
+		// !(n >= 0) also rejects NaN
+		if err != nil || !(n >= 0) || n > 1<<25 {
+			return 0
+		}
+		secs = secs*60 + n
+	}
+	return seconds(secs)
+}
+
+// seconds converts a playing time in seconds to a duration, returning 0
+// for one over a year, which no media file plays for.
+func seconds(secs float64) time.Duration {
+	if !(secs >= 0) || secs > 1<<25 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// parseCount parses a byte length or an episode number, returning 0 if s
+// is not a positive integer.
+func parseCount(s string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package rss
+
+import (
+	"reflect"
+	"rsshub/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEnclosures(t *testing.T) {
+	episode := []domain.Enclosure{
+		{Kind: domain.EnclosureFile, URL: "https://example.com/ep.mp3", Type: "audio/mpeg", Length: 1234, Duration: time.Hour + 2*time.Minute + 3*time.Second, Episode: 7},
+		{Kind: domain.EnclosureMedia, URL: "https://example.com/ep.ogg", Type: "audio/ogg", Length: 999},
+		{Kind: domain.EnclosureThumbnail, URL: "https://example.com/ep.jpg"},
+	}
+	tests := []struct {
+		name string
+		doc  string
+		want []domain.Enclosure
+	}{
+		{"rss", `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/"><channel><item>
+<title>Episode 7</title><itunes:title>Seven</itunes:title><media:title>Seven again</media:title>
+<enclosure url="https://example.com/ep.mp3" type="audio/mpeg" length="1234"/>
+<itunes:duration>1:02:03</itunes:duration><itunes:episode>7</itunes:episode>
+<media:content url="https://example.com/ep.mp3" type="audio/mpeg"/>
+<media:group><media:content url="https://example.com/ep.ogg" type="audio/ogg" fileSize="999"/><media:thumbnail url="https://example.com/ep.jpg"/></media:group>
+</item></channel></rss>`, episode},
+		{"atom", `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/"><entry>
+<title>Episode 7</title><content>text</content>
+<link rel="alternate" href="https://example.com/7"/><link rel="enclosure" href="https://example.com/ep.mp4" type="video/mp4" length="5"/>
+<media:content url="https://example.com/ep.webm" duration="90.5"/>
+</entry></feed>`, []domain.Enclosure{
+			{Kind: domain.EnclosureFile, URL: "https://example.com/ep.mp4", Type: "video/mp4", Length: 5},
+			{Kind: domain.EnclosureMedia, URL: "https://example.com/ep.webm", Duration: 90 * time.Second},
+		}},
+		{"json", `{"version":"https://jsonfeed.org/version/1.1","items":[{"id":"7","title":"Episode 7","attachments":[
+{"url":"https://example.com/ep.mp3","mime_type":"audio/mpeg","size_in_bytes":1234,"duration_in_seconds":3723}]}]}`, []domain.Enclosure{
+			{Kind: domain.EnclosureFile, URL: "https://example.com/ep.mp3", Type: "audio/mpeg", Length: 1234, Duration: time.Hour + 2*time.Minute + 3*time.Second},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parse("", strings.NewReader(tt.doc), Limits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != 1 {
+				t.Fatalf("got %d items", len(res.Items))
+			}
+			it := res.Items[0]
+			if it.Title != "Episode 7" {
+				t.Errorf("title %q, want the item's own", it.Title)
+			}
+			if !reflect.DeepEqual(it.Enclosures, tt.want) {
+				t.Errorf("enclosures:\n got %+v\nwant %+v", it.Enclosures, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"3723", 3723 * time.Second},
+		{"62:03", 62*time.Minute + 3*time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{" 45.9 ", 45 * time.Second},
+		{"", 0},
+		{"1:2:3:4", 0},
+		{"-5", 0},
+		{"NaN", 0},
+		{"soon", 0},
+		{"99999999:00:00", 0},
+	}
+	for _, tt := range tests {
+		if got := parseDuration(tt.in); got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
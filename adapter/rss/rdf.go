@@ -0,0 +1,53 @@
+package rss
+
+import (
+	"encoding/xml"
+	"rsshub/domain"
+	"strings"
+)
+
+// rdfFeed is an RSS 1.0 document. Unlike RSS 2.0, items are siblings of
+// <channel> directly under the <rdf:RDF> root.
+type rdfFeed struct {
+	XMLName xml.Name `xml:"RDF"`
+	Channel struct {
+		syndication
+	} `xml:"channel"`
+	Item xmlItems[rdfItem] `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string   `xml:"about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subject     []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+func parseRDF(dec *xml.Decoder, root *xml.StartElement, list *itemList) (domain.ScheduleHints, error) {
+	var rf rdfFeed
+	rf.Item.itemList = list
+	err := dec.DecodeElement(&rf, root)
+	return hintsFrom("", rf.Channel.syndication, nil, nil), err
+}
+
+func (it rdfItem) item() domain.FetchedItem {
+	link := strings.TrimSpace(it.Link)
+	if link == "" {
+		link = strings.TrimSpace(it.About)
+	}
+	published, _ := parseDate(it.Date)
+	return domain.FetchedItem{
+		GUID:        strings.TrimSpace(it.About),
+		Title:       strings.TrimSpace(it.Title),
+		Link:        link,
+		Description: strings.TrimSpace(it.Description),
+		Content:     strings.TrimSpace(it.Content),
+		PublishedAt: published,
+		Authors:     it.Creator,
+		Categories:  it.Subject,
+	}
+}
@@ -0,0 +1,51 @@
+package rss
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRDF(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>Feed</title>
+    <items><rdf:Seq><rdf:li rdf:resource="https://example.com/1"/><rdf:li rdf:resource="https://example.com/2"/></rdf:Seq></items>
+  </channel>
+  <item rdf:about="https://example.com/1">
+    <title> First </title>
+    <link>https://example.com/1?ref=rss</link>
+    <description>One</description>
+    <dc:date>2024-05-01T12:00:00Z</dc:date>
+    <dc:creator>Jane Doe</dc:creator>
+    <dc:subject>Go</dc:subject>
+  </item>
+  <item rdf:about="https://example.com/2">
+    <title>Second</title>
+  </item>
+</rdf:RDF>`
+
+	res, err := parse("application/rdf+xml", strings.NewReader(doc), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 2 {
+		t.Fatalf("got %d items, want the 2 beside the channel", len(res.Items))
+	}
+	first, second := res.Items[0], res.Items[1]
+	if first.GUID != "https://example.com/1" || first.Title != "First" || first.Link != "https://example.com/1?ref=rss" || first.Description != "One" {
+		t.Errorf("first item: %+v", first)
+	}
+	if !first.PublishedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("first item published %v", first.PublishedAt)
+	}
+	if !reflect.DeepEqual(first.Authors, []string{"Jane Doe"}) || !reflect.DeepEqual(first.Categories, []string{"Go"}) {
+		t.Errorf("first item authors %q, categories %q", first.Authors, first.Categories)
+	}
+	// without a link the item is identified by its rdf:about
+	if second.Link != "https://example.com/2" || !second.PublishedAt.IsZero() {
+		t.Errorf("second item: %+v", second)
+	}
+}
@@ -0,0 +1,99 @@
+package rss
+
+import (
+	"encoding/xml"
+	"rsshub/domain"
+	"strings"
+)
+
+type rssFeed struct {
+	Channel struct {
+		Title       string            `xml:"title"`
+		Link        string            `xml:"link"`
+		Description string            `xml:"description"`
+		TTL         string            `xml:"ttl"`
+		SkipHours   []string          `xml:"skipHours>hour"`
+		SkipDays    []string          `xml:"skipDays>day"`
+		Item        xmlItems[rssItem] `xml:"item"`
+		syndication
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	mediaExtensions
+	GUID        rssGUID        `xml:"guid"`
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string         `xml:"pubDate"`
+	Author      []string       `xml:"author"`
+	Creator     []string       `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Category    []string       `xml:"category"`
+	Comments    string         `xml:"comments"`
+	Enclosure   []rssEnclosure `xml:"enclosure"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// rssGUID is an item's <guid>. Unless isPermaLink is "false" it is also
+// the item's URL.
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+func parseRSS(dec *xml.Decoder, root *xml.StartElement, list *itemList) (domain.ScheduleHints, error) {
+	var rf rssFeed
+	rf.Channel.Item.itemList = list
+	err := dec.DecodeElement(&rf, root)
+	ch := rf.Channel
+	return hintsFrom(ch.TTL, ch.syndication, ch.SkipHours, ch.SkipDays), err
+}
+
+func (it rssItem) item() domain.FetchedItem {
+	published, _ := parseDate(it.PubDate)
+	guid := strings.TrimSpace(it.GUID.Value)
+	link := strings.TrimSpace(it.Link)
+	if link == "" && it.GUID.IsPermaLink != "false" {
+		link = guid
+	}
+	authors := make([]string, 0, len(it.Author)+len(it.Creator))
+	for _, a := range it.Author {
+		authors = append(authors, authorName(a))
+	}
+	files := make([]domain.Enclosure, len(it.Enclosure))
+	for i, e := range it.Enclosure {
+		files[i] = domain.Enclosure{Kind: domain.EnclosureFile, URL: e.URL, Type: e.Type, Length: parseCount(e.Length)}
+	}
+	return domain.FetchedItem{
+		GUID:        guid,
+		Title:       it.Title,
+		Link:        link,
+		Description: it.Description,
+		Content:     strings.TrimSpace(it.Content),
+		PublishedAt: published,
+		Authors:     append(authors, it.Creator...),
+		Categories:  it.Category,
+		Comments:    strings.TrimSpace(it.Comments),
+		Enclosures:  it.enclosures(files...),
+	}
+}
+
+// authorName returns the name in an RSS <author>, which is meant to be an
+// email address optionally followed by the name in parentheses, as in
+// "jdoe@example.com (John Doe)". Other values are kept as they are.
+func authorName(author string) string {
+	author = strings.TrimSpace(author)
+	if open := strings.IndexByte(author, '('); open > 0 && strings.HasSuffix(author, ")") &&
+		strings.Contains(author[:open], "@") {
+		if name := strings.TrimSpace(author[open+1 : len(author)-1]); name != "" {
+			return name
+		}
+	}
+	return author
+}
@@ -0,0 +1,57 @@
+package rss
+
+import (
+	"net/http"
+	"rsshub/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter caps publisher-requested delays so a bogus header cannot
+// park a feed indefinitely.
+const maxRetryAfter = 24 * time.Hour
+
+// statusError maps a non-success response to a *domain.FetchError.
+func statusError(resp *http.Response) error {
+	fe := &domain.FetchError{StatusCode: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		fe.Kind = domain.ErrFeedNotFound
+	case resp.StatusCode == http.StatusGone:
+		fe.Kind = domain.ErrFeedGone
+	case resp.StatusCode == http.StatusTooManyRequests:
+		fe.Kind = domain.ErrRateLimited
+		fe.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode >= 500:
+		fe.Kind = domain.ErrServerError
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			fe.RetryAfter = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+	default:
+		fe.Kind = domain.ErrUnexpectedReply
+	}
+	return fe
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date. Invalid or past values yield 0.
+func retryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(now)
+	}
+	if d <= 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
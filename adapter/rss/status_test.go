@@ -0,0 +1,74 @@
+package rss
+
+import (
+	"errors"
+	"net/http"
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 30 ", 30 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"999999", maxRetryAfter},
+		{"Tue, 05 Mar 2024 10:05:00 GMT", 5 * time.Minute},
+		{"Tuesday, 05-Mar-24 11:00:00 GMT", time.Hour},
+		{"Tue Mar  5 10:00:30 2024", 30 * time.Second},
+		{"Tue, 05 Mar 2024 09:00:00 GMT", 0},
+		{"Fri, 08 Mar 2024 10:00:00 GMT", maxRetryAfter},
+		{"soon", 0},
+		{"1.5", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.in, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status     int
+		retryAfter string
+		kind       error
+		wantRetry  time.Duration
+	}{
+		{http.StatusNotFound, "", domain.ErrFeedNotFound, 0},
+		{http.StatusGone, "", domain.ErrFeedGone, 0},
+		{http.StatusTooManyRequests, "60", domain.ErrRateLimited, time.Minute},
+		{http.StatusTooManyRequests, "", domain.ErrRateLimited, 0},
+		{http.StatusServiceUnavailable, "60", domain.ErrServerError, time.Minute},
+		{http.StatusInternalServerError, "60", domain.ErrServerError, 0},
+		{http.StatusBadGateway, "", domain.ErrServerError, 0},
+		{http.StatusForbidden, "60", domain.ErrUnexpectedReply, 0},
+		{http.StatusNoContent, "", domain.ErrUnexpectedReply, 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		err := statusError(resp)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("HTTP %d: %v, want %v", tt.status, err, tt.kind)
+			continue
+		}
+		var fe *domain.FetchError
+		if !errors.As(err, &fe) {
+			t.Errorf("HTTP %d: %T is not a *domain.FetchError", tt.status, err)
+			continue
+		}
+		if fe.StatusCode != tt.status || fe.RetryAfter != tt.wantRetry {
+			t.Errorf("HTTP %d: status %d, retry after %v; want %d, %v", tt.status, fe.StatusCode, fe.RetryAfter, tt.status, tt.wantRetry)
+		}
+	}
+}
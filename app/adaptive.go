@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"rsshub/domain"
+	"time"
+)
+
+// publishWindow is how many of a feed's newest articles are used to
+// estimate its publish rate.
+const publishWindow = 20
+
+// adaptive reports whether per-feed intervals are learned.
+func (a *AggregatorService) adaptive() bool {
+	return a.opts.AdaptiveMin > 0 && a.opts.AdaptiveMax >= a.opts.AdaptiveMin
+}
+
+// learnInterval estimates a polling interval for f from its recent
+// publication times. It returns 0 when adaptive polling is off or the feed
+// has too little history, in which case the global interval applies.
+func (a *AggregatorService) learnInterval(ctx context.Context, f domain.Feed) (time.Duration, error) {
+	if !a.adaptive() {
+		return 0, nil
+	}
+	st, err := a.repo.PublishStats(ctx, f.ID, publishWindow)
+	if err != nil {
+		return 0, err
+	}
+	return adaptiveInterval(st, time.Now(), a.opts.AdaptiveMin, a.opts.AdaptiveMax), nil
+}
+
+// adaptiveInterval polls about twice per expected article. The expected gap
+// is the average gap between recent articles, or the time since the newest
+// one if that is longer, so feeds that went quiet slow down gradually.
+func adaptiveInterval(st domain.PublishStats, now time.Time, lo, hi time.Duration) time.Duration {
+	if st.Count < 2 {
+		return 0
+	}
+	gap := st.Newest.Sub(st.Oldest) / time.Duration(st.Count-1)
+	if since := now.Sub(st.Newest); since > gap {
+		gap = since
+	}
+	return min(max(gap/2, lo), hi).Round(time.Second)
+}
@@ -3,28 +3,114 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"rsshub/domain"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	// pruneEvery is how often expired fetch history is deleted.
+	pruneEvery = time.Hour
+	// defaultHeartbeat is used when Options.Heartbeat is not set.
+	defaultHeartbeat = 10 * time.Second
+	// defaultDrainTimeout is used when Options.DrainTimeout is not set.
+	defaultDrainTimeout = 30 * time.Second
+	// defaultLeaseDuration is used when Options.LeaseDuration is not set.
+	defaultLeaseDuration = 2 * time.Minute
+)
+
+// ErrDrainTimeout is returned by Stop when feeds were still being polled
+// at the drain deadline and had to be interrupted.
+var ErrDrainTimeout = errors.New("drain deadline exceeded")
+
+// Options holds the tunables of an AggregatorService that are fixed for its
+// lifetime. Zero values disable the corresponding feature.
+type Options struct {
+	// Heartbeat is how often the scheduler looks for due feeds. It bounds
+	// how late a feed can be polled, not how often it is polled.
+	Heartbeat time.Duration
+
+	// DrainTimeout is how long Stop waits for feeds being polled to
+	// finish before interrupting them.
+	DrainTimeout time.Duration
+
+	// InstanceID identifies this aggregator among those sharing the
+	// database; it defaults to the host name and PID. LeaseDuration is how
+	// long a claimed feed is kept from the others, and must be longer
+	// than a poll takes. A crashed instance's feeds are polled again once
+	// its leases expire.
+	InstanceID    string
+	LeaseDuration time.Duration
+
+	// HistoryRetention is how long fetch attempts are kept.
+	HistoryRetention time.Duration
+
+	// BackoffBase and BackoffMax bound the delay before a failing feed is
+	// polled again; the delay doubles with every consecutive failure.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// FailureThreshold is the number of consecutive failures after which
+	// a feed is disabled.
+	FailureThreshold int
+
+	// AdaptiveMin and AdaptiveMax bound the intervals learned from each
+	// feed's publish rate. Feeds with an explicit interval are not affected.
+	AdaptiveMin time.Duration
+	AdaptiveMax time.Duration
+
+	// Metrics, if set, receives per-poll measurements.
+	Metrics domain.Metrics
+
+	// Logger receives the aggregator's logs; it defaults to slog.Default.
+	Logger *slog.Logger
+}
+
 type AggregatorService struct {
 	repo    domain.FeedRepository
 	fetcher domain.RSSFetcher
-
-	mu             sync.Mutex
-	interval       time.Duration
-	workers        int
-	jobs           chan domain.Feed
-	ctx            context.Context
-	cancel         context.CancelFunc
-	tickerStopChan chan struct{}
-	started        bool
-	workerCancels  []context.CancelFunc
+	opts    Options
+
+	mu            sync.Mutex
+	interval      time.Duration
+	workers       int
+	queue         *feedQueue
+	ctx           context.Context
+	cancel        context.CancelFunc
+	workCtx       context.Context
+	workCancel    context.CancelFunc
+	started       bool
+	workerCancels []context.CancelFunc
+	running       sync.WaitGroup
+	pausedAt      time.Time
+	wake          chan struct{}
+	lastPrune     time.Time
+
+	stats runtimeStats
 }
 
-func NewAggregator(repo domain.FeedRepository, fetcher domain.RSSFetcher, interval time.Duration, workers int) *AggregatorService {
-	return &AggregatorService{repo: repo, fetcher: fetcher, interval: interval, workers: workers}
+// NewAggregator creates an aggregator. interval is the polling interval of
+// feeds that do not have one of their own.
+func NewAggregator(repo domain.FeedRepository, fetcher domain.RSSFetcher, interval time.Duration, workers int, opts Options) *AggregatorService {
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = defaultHeartbeat
+	}
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = defaultDrainTimeout
+	}
+	if opts.InstanceID == "" {
+		opts.InstanceID = defaultInstanceID()
+	}
+	if opts.LeaseDuration <= 0 {
+		opts.LeaseDuration = defaultLeaseDuration
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	opts.Logger = opts.Logger.With("instance", opts.InstanceID)
+	return &AggregatorService{repo: repo, fetcher: fetcher, interval: interval, workers: workers, opts: opts, queue: newFeedQueue(), wake: make(chan struct{}, 1)}
 }
 
 func (a *AggregatorService) Start(ctx context.Context) error {
@@ -42,23 +128,28 @@ func (a *AggregatorService) Start(ctx context.Context) error {
 	// or we explicitly call a.cancel()
 	a.ctx, a.cancel = context.WithCancel(ctx)
 
-	// init jobs channel if it hasnt been created yet
-	// this channel is where feed tasks will be send to workers
-	if a.jobs == nil {
-		a.jobs = make(chan domain.Feed)
-	}
-
-	// create a channel to signal when the ticker (periodic fetch loop) should stop
-	a.tickerStopChan = make(chan struct{})
+	// feeds are polled under a separate context that outlives ctx, so a
+	// poll in progress at shutdown can finish; Stop cancels it only once
+	// the drain deadline has passed
+	a.workCtx, a.workCancel = context.WithCancel(context.WithoutCancel(ctx))
 
 	// reset any existing workercancel functions
 	a.workerCancels = nil
 
+	// a restarted aggregator is never paused
+	a.pausedAt = time.Time{}
+	a.queue.hold(false)
+
+	a.stats.mu.Lock()
+	a.stats.startedAt = time.Now()
+	a.stats.mu.Unlock()
+
 	// start the configured number of worker goroutines
 	startWorkersCount(a, a.workers)
 
 	// start main aggregator loop in background
 	// this loop typically handles the periodic scheduling of feed fetches
+	a.running.Add(1)
 	go a.loop()
 
 	// mark aggregator as started
@@ -67,6 +158,9 @@ func (a *AggregatorService) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop stops the scheduler and the workers. Feeds being polled are given
+// until the drain deadline to finish; any still running then are
+// interrupted and reported in the returned error.
 func (a *AggregatorService) Stop() error {
 	// lock mutex to make sure only one goroutine can stop the service at a time
 	a.mu.Lock()
@@ -78,12 +172,10 @@ func (a *AggregatorService) Stop() error {
 	}
 
 	// save references we wil need after unlocking
-	// cancel: cancels the aggregator main context
-	// stopCh: channel to signal the ticker loop to stop
-	// cancels: list of cancel functions for all worker goroutines
+	// cancel: stops the loop and tells every worker to exit once idle
+	// workCancel: interrupts the polls still running at the deadline
 	cancel := a.cancel
-	stopCh := a.tickerStopChan
-	cancels := append([]context.CancelFunc(nil), a.workerCancels...)
+	workCancel := a.workCancel
 
 	// mark aggregator as stopped
 	a.started = false
@@ -91,41 +183,57 @@ func (a *AggregatorService) Stop() error {
 	// unlock early since we dont neeed to hold the lock during shutdown
 	a.mu.Unlock()
 
-	// close the stop channel tells the loop goroutine to exit
-	close(stopCh)
-
-	// Cancel the aggregators context any operations depending on it will stop
+	// Cancel the aggregators context the loop and idle workers will stop,
+	// busy workers stop after their current feed
 	cancel()
 
-	// call cancel function for each worker
-	for _, c := range cancels {
-		c()
+	drained := make(chan struct{})
+	go func() {
+		a.running.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(a.opts.DrainTimeout)
+	defer timer.Stop()
+
+	var interrupted []string
+	select {
+	case <-drained:
+	case <-timer.C:
+		// deadline passed, interrupt whatever is still being polled
+		for _, w := range a.Status().Workers {
+			if w.Feed != "" {
+				interrupted = append(interrupted, w.Feed)
+			}
+		}
+		workCancel()
+		<-drained
 	}
+	workCancel()
 
-	return nil
+	// hand the feeds this instance claimed but did not poll to the others
+	a.queue.reset()
+	a.releaseAll()
+
+	if len(interrupted) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w, interrupted feeds: %s", ErrDrainTimeout, strings.Join(interrupted, ", "))
 }
 
-func (a *AggregatorService) SetInterval(d time.Duration) {
+// SetInterval changes the polling interval of feeds without their own. It
+// applies from each feed's next poll; the scheduler heartbeat is unaffected.
+func (a *AggregatorService) SetInterval(d time.Duration) error {
+	// a non-positive interval would poll every feed on every heartbeat
+	if d <= 0 {
+		return errors.New("interval must be > 0")
+	}
+
 	// lock to make sure multiple goroutines wont change interval at the same time
 	a.mu.Lock()
 	defer a.mu.Unlock()
-
-	// if aggregator is to running yet
-	//	just update interval value so that when Start() is called it will use the new interval
-	if !a.started {
-		a.interval = d
-		return
-	}
-
-	// if aggregator already running
-	// close old ticker stop channel
-	close(a.tickerStopChan)
-
-	// create a new stop channel for the new ticker
-	a.tickerStopChan = make(chan struct{})
-
-	// update interval value to the new durations
 	a.interval = d
+	return nil
 }
 
 func (a *AggregatorService) Resize(workers int) error {
@@ -143,6 +251,12 @@ func (a *AggregatorService) Resize(workers int) error {
 		return nil
 	}
 
+	// not running, the new count applies from the next Start
+	if !a.started {
+		a.workers = workers
+		return nil
+	}
+
 	// case 1: we need to increase number of workers
 	if workers > a.workers {
 		delta := workers - a.workers // how many more workers to start
@@ -161,6 +275,7 @@ func (a *AggregatorService) Resize(workers int) error {
 			a.workerCancels = a.workerCancels[:idx]
 
 			// Call the cancel function to stop that worker goroutine
+			// once it has finished its current feed
 			c()
 		}
 	}
@@ -184,87 +299,119 @@ func (a *AggregatorService) CurrentWorkers() int {
 	return a.workers
 }
 
+// loop is the scheduler: on every heartbeat, and whenever a worker frees
+// up, it claims due feeds for the workers and prunes old fetch history,
+// unless paused.
 func (a *AggregatorService) loop() {
-	for {
-		// lock and copy state so we dont need to keep the lock during long operations
-		a.mu.Lock()
-		interval := a.interval     // how often to fetch feeds
-		stopCh := a.tickerStopChan // channel used to signal ticker reser
-		jobs := a.jobs             // channel for send feeds to workers
-		workers := a.workers       // current number of workers
-		a.mu.Unlock()
+	defer a.running.Done()
+	ticker := time.NewTicker(a.opts.Heartbeat)
+	defer ticker.Stop()
 
-		// create a new ticker that ticks every `interval`
-		ticker := time.NewTicker(interval)
+	for {
+		a.stats.tick()
+		if !a.paused() {
+			a.dispatchDue()
+			a.pruneHistory()
+		}
 
-		// wait for  one of the following events
 		select {
 		case <-a.ctx.Done():
-			// if aggregator context is canceled
-			// stop ticker and exit the loop graceful shutdown
-			ticker.Stop()
+			// aggregator stopped, graceful shutdown
 			return
-		case <-stopCh:
-			// if interval was changed (`SetInterval` called)
-			// close old ticker and restart loop with new interval
-			ticker.Stop()
-			continue
 		case <-ticker.C:
-			// ticker fired time to fetch feeds
+		case <-a.wake:
 		}
+	}
+}
 
-		// fetch stale feeds that need updating
-		// pass the current number of workers so we dont overload
-		feeds, err := a.repo.GetStaleFeeds(a.ctx, workers)
-		if err == nil {
-			// send each feed to the jibs channel for workers to process
-			for _, f := range feeds {
-				select {
-				case jobs <- f: // hand off work to a worker
-				case <-a.ctx.Done():
-					// if aggregator is shutting down exit immediately
-					return
-				}
-			}
+// dispatchDue claims due feeds and queues them for the workers. It claims
+// no more than the workers can start on right away, leaving the rest to
+// other instances sharing the database.
+func (a *AggregatorService) dispatchDue() {
+	free := a.CurrentWorkers() - a.queue.load()
+	if free <= 0 {
+		return
+	}
+	feeds, err := a.repo.ClaimDueFeeds(a.ctx, a.opts.InstanceID, free, a.opts.LeaseDuration)
+	if err != nil {
+		if a.ctx.Err() == nil {
+			a.opts.Logger.Error("claim due feeds", "err", err)
+			a.stats.recordError("", "claim due feeds: "+err.Error())
+		}
+		return
+	}
+	if len(feeds) > 0 {
+		a.opts.Logger.Debug("claimed due feeds", "count", len(feeds), "free_workers", free)
+	}
+	var unused []*job
+	for _, f := range feeds {
+		if !a.queue.push(f) {
+			unused = append(unused, &job{feed: f})
 		}
 	}
+	a.release(unused)
+}
+
+// pollInterval returns how long to wait after polling f before polling it
+// again: its own interval if set, else the learned one, else the global one.
+func (a *AggregatorService) pollInterval(f domain.Feed) time.Duration {
+	if f.PollInterval > 0 {
+		return f.PollInterval
+	}
+	if a.adaptive() && f.LearnedInterval > 0 {
+		return f.LearnedInterval
+	}
+	return a.CurrentInterval()
+}
+
+// pruneHistory deletes fetch attempts older than the retention period,
+// at most once every pruneEvery. It is only called from loop.
+func (a *AggregatorService) pruneHistory() {
+	if a.opts.HistoryRetention <= 0 || time.Since(a.lastPrune) < pruneEvery {
+		return
+	}
+	a.lastPrune = time.Now()
+	n, err := a.repo.PruneFetchAttempts(a.ctx, time.Now().Add(-a.opts.HistoryRetention))
+	if err != nil {
+		a.opts.Logger.Error("prune fetch history", "err", err)
+		return
+	}
+	a.opts.Logger.Debug("pruned fetch history", "deleted", n)
 }
 
 func startWorkersCount(a *AggregatorService, count int) {
 	for i := 0; i < count; i++ {
-		wctx, cancel := context.WithCancel(a.ctx)
+		quit, cancel := context.WithCancel(a.ctx)
 		a.workerCancels = append(a.workerCancels, cancel)
-		go worker(wctx, a.repo, a.fetcher, a.jobs)
+		a.running.Add(1)
+		go worker(quit, a, a.stats.addWorker())
 	}
 }
 
-func worker(ctx context.Context, repo domain.FeedRepository, fetcher domain.RSSFetcher, jobs <-chan domain.Feed) {
+// worker polls queued feeds until quit is cancelled. quit only stops it
+// from taking new jobs; the feed in hand is polled under a.workCtx so it
+// is not cut short by a resize or the start of a shutdown.
+func worker(quit context.Context, a *AggregatorService, id int) {
+	defer a.running.Done()
+	defer a.stats.removeWorker(id)
+	log := a.opts.Logger.With("worker", id)
+	log.Debug("worker started")
+	defer log.Debug("worker stopped")
 	for {
-		select {
-		case <-ctx.Done():
+		j, ok := a.queue.pop(quit)
+		if !ok {
 			return
-		case f, ok := <-jobs:
-			if !ok {
-				return
-			}
-			processFeed(ctx, repo, fetcher, f)
 		}
-	}
-}
-
-func processFeed(ctx context.Context, repo domain.FeedRepository, fetcher domain.RSSFetcher, f domain.Feed) {
-	items, err := fetcher.Fetch(ctx, f.URL)
-	if err != nil {
-		return
-	}
-	for _, it := range items {
-		_ = repo.UpsertArticle(ctx, domain.Article{
-			Title:       it.Title,
-			Link:        it.Link,
-			Description: it.Description,
-			PublishedAt: it.PublishedAt,
-			FeedID:      f.ID,
+		a.stats.setWorkerFeed(id, j.feed.Name)
+		attempt := a.runJob(j, log.With("feed_id", j.feed.ID, "feed", j.feed.Name))
+		a.stats.setWorkerFeed(id, "")
+		a.queue.done(j)
+		a.wakeScheduler()
+		j.finish(domain.RefreshResult{
+			Feed:         j.feed.Name,
+			ItemsNew:     attempt.ItemsNew,
+			ItemsUpdated: attempt.ItemsUpdated,
+			Error:        attempt.Error,
 		})
 	}
-	_ = repo.MarkFeedPolled(ctx, f.ID)
 }
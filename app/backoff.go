@@ -0,0 +1,26 @@
+package app
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// backoffDelay returns how long to wait before polling a feed that has
+// failed failures times in a row: base doubled per extra failure, capped at
+// max, with the upper half randomised so feeds that broke together do not
+// retry in lockstep. A non-positive base disables backoff.
+func backoffDelay(failures int, base, max time.Duration) time.Duration {
+	if base <= 0 || failures <= 0 {
+		return 0
+	}
+	d := base
+	for i := 1; i < failures && d < math.MaxInt64/2 && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
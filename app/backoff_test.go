@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures  int
+		base, max time.Duration
+		want      time.Duration // before jitter
+	}{
+		{0, time.Minute, time.Hour, 0},
+		{1, 0, time.Hour, 0},
+		{1, time.Minute, time.Hour, time.Minute},
+		{2, time.Minute, time.Hour, 2 * time.Minute},
+		{4, time.Minute, time.Hour, 8 * time.Minute},
+		{7, time.Minute, time.Hour, time.Hour},
+		{1000, time.Minute, time.Hour, time.Hour},
+		{3, time.Hour, 90 * time.Minute, 90 * time.Minute},
+		{10, time.Minute, 0, 512 * time.Minute},
+	}
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		for range 100 {
+			got := backoffDelay(tt.failures, tt.base, tt.max)
+			if got < tt.want/2 || got > tt.want {
+				t.Fatalf("backoffDelay(%d, %v, %v) = %v, want between %v and %v", tt.failures, tt.base, tt.max, got, tt.want/2, tt.want)
+			}
+			seen[got] = true
+		}
+		if tt.want > 0 && len(seen) == 1 {
+			t.Errorf("backoffDelay(%d, %v, %v) not randomised", tt.failures, tt.base, tt.max)
+		}
+	}
+}
+
+func TestBackoffDelayNoOverflow(t *testing.T) {
+	if got := backoffDelay(200, time.Second, 0); got <= 0 {
+		t.Fatalf("uncapped backoff overflowed to %v", got)
+	}
+}
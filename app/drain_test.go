@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rsshub/domain"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drainRepo hands out one due feed and records whether it was marked
+// polled. Any other call panics on the nil embedded interface.
+type drainRepo struct {
+	domain.FeedRepository
+
+	mu      sync.Mutex
+	due     []domain.Feed
+	polled  bool
+	attempt domain.FetchAttempt
+}
+
+func (r *drainRepo) ClaimDueFeeds(ctx context.Context, owner string, limit int, lease time.Duration) ([]domain.Feed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	due := r.due
+	r.due = nil
+	return due, nil
+}
+
+func (r *drainRepo) MarkFeedPolled(ctx context.Context, feedID string, o domain.PollOutcome) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.polled = true
+	return nil
+}
+
+func (r *drainRepo) RecordFetchAttempt(ctx context.Context, a domain.FetchAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempt = a
+	return nil
+}
+
+func (r *drainRepo) ReleaseFeed(ctx context.Context, feedID, owner string) error { return nil }
+
+func (r *drainRepo) ReleaseFeeds(ctx context.Context, owner string) error { return nil }
+
+// slowFetcher reports each fetch on started and answers 304 after delay,
+// or fails once its context is cancelled.
+type slowFetcher struct {
+	delay   time.Duration
+	started chan struct{}
+}
+
+func (f slowFetcher) Fetch(ctx context.Context, feed domain.Feed) (domain.FetchResult, error) {
+	f.started <- struct{}{}
+	select {
+	case <-time.After(f.delay):
+		return domain.FetchResult{StatusCode: 304, NotModified: true}, nil
+	case <-ctx.Done():
+		return domain.FetchResult{}, ctx.Err()
+	}
+}
+
+func startDraining(t *testing.T, fetchDelay, drainTimeout time.Duration) (*AggregatorService, *drainRepo) {
+	t.Helper()
+	repo := &drainRepo{due: []domain.Feed{{ID: "f1", Name: "slow"}}}
+	fetcher := slowFetcher{delay: fetchDelay, started: make(chan struct{}, 1)}
+	a := NewAggregator(repo, fetcher, time.Minute, 1, Options{Heartbeat: time.Hour, DrainTimeout: drainTimeout, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-fetcher.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("feed not polled")
+	}
+	return a, repo
+}
+
+func TestStopDrainsInFlightPoll(t *testing.T) {
+	a, repo := startDraining(t, 50*time.Millisecond, 5*time.Second)
+
+	if err := a.Stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if !repo.polled || repo.attempt.Error != "" {
+		t.Fatalf("polled %v, attempt error %q; want the poll finished", repo.polled, repo.attempt.Error)
+	}
+}
+
+func TestStopInterruptsAtDrainDeadline(t *testing.T) {
+	a, repo := startDraining(t, time.Hour, 50*time.Millisecond)
+
+	start := time.Now()
+	err := a.Stop()
+	if !errors.Is(err, ErrDrainTimeout) || !strings.Contains(err.Error(), "slow") {
+		t.Fatalf("stop: %v, want %v naming the slow feed", err, ErrDrainTimeout)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Fatalf("stop took %v", took)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.polled {
+		t.Fatal("interrupted feed marked polled")
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"rsshub/domain"
+	"time"
+)
+
+// releaseTimeout bounds releasing the leases at shutdown.
+const releaseTimeout = 5 * time.Second
+
+// errLeased is reported for a manual refresh of a feed another instance is
+// polling.
+const errLeased = "feed is being polled by another instance"
+
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// runJob polls the feed of j under this instance's lease and releases the
+// lease afterwards. Scheduled jobs were claimed by dispatchDue; manual
+// refreshes claim their feed here so they never poll a feed that another
+// instance is polling.
+func (a *AggregatorService) runJob(j *job, log *slog.Logger) domain.FetchAttempt {
+	ctx := a.workCtx
+	if j.urgent {
+		ok, err := a.repo.ClaimFeed(ctx, j.feed.ID, a.opts.InstanceID, a.opts.LeaseDuration)
+		if err != nil {
+			log.Error("claim feed", "err", err)
+			return domain.FetchAttempt{FeedID: j.feed.ID, Error: "claim feed: " + err.Error()}
+		}
+		if !ok {
+			log.Info("manual refresh skipped, feed leased by another instance")
+			return domain.FetchAttempt{FeedID: j.feed.ID, Error: errLeased}
+		}
+	}
+
+	attempt := a.processFeed(ctx, j.feed, log)
+	if err := a.repo.ReleaseFeed(ctx, j.feed.ID, a.opts.InstanceID); err != nil && ctx.Err() == nil {
+		log.Error("release feed", "err", err)
+	}
+	return attempt
+}
+
+// release ends the leases on the feeds of jobs that will not be run.
+func (a *AggregatorService) release(jobs []*job) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	for _, j := range jobs {
+		if err := a.repo.ReleaseFeed(ctx, j.feed.ID, a.opts.InstanceID); err != nil {
+			a.opts.Logger.Error("release feed", "feed_id", j.feed.ID, "feed", j.feed.Name, "err", err)
+		}
+	}
+}
+
+// releaseAll ends every lease this instance holds, so other instances can
+// take over its feeds without waiting for the leases to expire.
+func (a *AggregatorService) releaseAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := a.repo.ReleaseFeeds(ctx, a.opts.InstanceID); err != nil {
+		a.opts.Logger.Error("release leases", "err", err)
+	}
+}
+
+// wakeScheduler makes loop look for due feeds now rather than at the next
+// heartbeat, since dispatchDue only claims feeds for idle workers.
+func (a *AggregatorService) wakeScheduler() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+// claimRepo hands out the given feeds as due and records the leases
+// released. Any other call panics on the nil embedded interface.
+type claimRepo struct {
+	domain.FeedRepository
+	due      []domain.Feed
+	released []string
+}
+
+func (r *claimRepo) ClaimDueFeeds(ctx context.Context, owner string, limit int, lease time.Duration) ([]domain.Feed, error) {
+	return r.due, nil
+}
+
+func (r *claimRepo) ReleaseFeed(ctx context.Context, feedID, owner string) error {
+	r.released = append(r.released, feedID)
+	return nil
+}
+
+func TestDispatchDueReleasesUnqueuedLeases(t *testing.T) {
+	refreshed := domain.Feed{ID: "refreshed", Name: "refreshed"}
+	scheduled := domain.Feed{ID: "scheduled", Name: "scheduled"}
+	fresh := domain.Feed{ID: "fresh", Name: "fresh"}
+	repo := &claimRepo{due: []domain.Feed{refreshed, scheduled, fresh}}
+	a := NewAggregator(repo, nil, time.Minute, 10, Options{})
+	a.ctx = context.Background()
+
+	a.queue.pushUrgent(refreshed, make(chan domain.RefreshResult, 1))
+	a.queue.push(scheduled)
+	a.dispatchDue()
+
+	// the refresh claims its feed when it runs, while the scheduled job
+	// queued before relies on the lease
+	if len(repo.released) != 1 || repo.released[0] != "refreshed" {
+		t.Fatalf("released %q, want only the refreshed feed", repo.released)
+	}
+	if n := a.queue.len(); n != 3 {
+		t.Fatalf("%d jobs queued, want 3", n)
+	}
+}
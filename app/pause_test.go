@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+// pauseRepo knows one feed and records the leases released. Any other
+// call panics on the nil embedded interface.
+type pauseRepo struct {
+	domain.FeedRepository
+	feed     domain.Feed
+	released chan string
+}
+
+func (r *pauseRepo) GetFeedByName(ctx context.Context, name string) (domain.Feed, error) {
+	return r.feed, nil
+}
+
+func (r *pauseRepo) ReleaseFeed(ctx context.Context, feedID, owner string) error {
+	r.released <- feedID
+	return nil
+}
+
+func TestPauseAnswersQueuedRefresh(t *testing.T) {
+	repo := &pauseRepo{feed: domain.Feed{ID: "f1", Name: "feed"}, released: make(chan string, 1)}
+	a := NewAggregator(repo, nil, time.Minute, 0, Options{})
+	// started without workers, so the refresh stays queued
+	a.started, a.ctx = true, context.Background()
+
+	type outcome struct {
+		results []domain.RefreshResult
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := a.Refresh(context.Background(), []string{"feed"})
+		done <- outcome{results, err}
+	}()
+	for a.queue.len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := a.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case o := <-done:
+		if o.err != nil || len(o.results) != 1 || o.results[0].Error != ErrPaused.Error() {
+			t.Fatalf("got %+v, err %v; want the refresh answered with %v", o.results, o.err, ErrPaused)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresh still waiting after pause")
+	}
+	if id := <-repo.released; id != "f1" || a.queue.len() != 0 {
+		t.Fatalf("released %q, %d jobs left queued", id, a.queue.len())
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rsshub/domain"
+	"time"
+)
+
+// processFeed polls f, records the attempt in the fetch history and
+// returns it. log carries the feed's attributes.
+func (a *AggregatorService) processFeed(ctx context.Context, f domain.Feed, log *slog.Logger) domain.FetchAttempt {
+	log.Debug("polling feed", "url", f.URL)
+	attempt := domain.FetchAttempt{FeedID: f.ID, StartedAt: time.Now()}
+	err := a.pollFeed(ctx, f, &attempt, log)
+	attempt.FinishedAt = time.Now()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	// the poll was cut short at the shutdown drain deadline, not by the feed
+	if ctx.Err() != nil {
+		attempt.Error = "interrupted: " + ctx.Err().Error()
+		log.Warn("poll interrupted", "err", ctx.Err())
+		return attempt
+	}
+	log.Debug("polled feed", "status", attempt.StatusCode, "bytes", attempt.Bytes, "parsed", attempt.ItemsParsed,
+		"new", attempt.ItemsNew, "updated", attempt.ItemsUpdated, "duration", attempt.FinishedAt.Sub(attempt.StartedAt), "err", attempt.Error)
+	if m := a.opts.Metrics; m != nil {
+		m.FetchCompleted(f.Name, fetchOutcome(err, attempt.StatusCode), attempt.FinishedAt.Sub(attempt.StartedAt), attempt.Bytes)
+		m.ArticlesStored(f.Name, attempt.ItemsNew, attempt.ItemsUpdated)
+	}
+	if attempt.Error != "" {
+		a.stats.recordError(f.Name, attempt.Error)
+	}
+	if err := a.repo.RecordFetchAttempt(ctx, attempt); err != nil {
+		log.Error("record fetch attempt", "err", err)
+	}
+	return attempt
+}
+
+// pollFeed fetches f and stores its articles, filling in attempt as it goes.
+func (a *AggregatorService) pollFeed(ctx context.Context, f domain.Feed, attempt *domain.FetchAttempt, log *slog.Logger) error {
+	res, err := a.fetcher.Fetch(ctx, f)
+	attempt.StatusCode = res.StatusCode
+	attempt.Bytes = res.Bytes
+	if err != nil {
+		a.handleFetchError(ctx, f, err, log)
+		return err
+	}
+	// the publisher moved the feed for good, so follow it from now on
+	if res.MovedTo != "" && res.MovedTo != f.URL {
+		if err := a.repo.UpdateFeedURL(ctx, f.ID, res.MovedTo); err != nil {
+			return fmt.Errorf("update feed url: %w", err)
+		}
+		log.Info("feed moved permanently", "from", f.URL, "to", res.MovedTo)
+	}
+	// a 304 is a successful poll with nothing new to store
+	if res.NotModified {
+		return a.markPolled(ctx, f, res.Validators, f.Hints, log)
+	}
+
+	attempt.ItemsParsed = len(res.Items)
+	attempt.Truncated = res.Truncated.String()
+	if attempt.Truncated != "" {
+		log.Warn("feed truncated to fetch limits", "truncated", attempt.Truncated)
+	}
+	articles := make([]domain.Article, len(res.Items))
+	for i, it := range res.Items {
+		articles[i] = domain.Article{
+			GUID:        it.GUID,
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Description,
+			Content:     it.Content,
+			PublishedAt: it.PublishedAt,
+			Authors:     it.Authors,
+			Categories:  it.Categories,
+			Comments:    it.Comments,
+			Enclosures:  it.Enclosures,
+			FeedID:      f.ID,
+		}
+	}
+
+	// the articles and the poll mark are written in one transaction, so
+	// the interval is learned from the articles of earlier polls
+	c, err := a.repo.UpsertArticles(ctx, f.ID, articles, a.pollOutcome(ctx, f, res.Validators, res.Hints, log))
+	if err != nil {
+		// nothing was stored, so the poll failed: the feed backs off like
+		// after a failed fetch and keeps the validators of the last poll
+		// that was stored, so the articles are fetched again
+		err = fmt.Errorf("store %d articles: %w", len(articles), err)
+		a.handleFetchError(ctx, f, err, log)
+		return err
+	}
+	attempt.ItemsNew = c.Inserted
+	attempt.ItemsUpdated = c.Updated
+	log.Debug("stored articles", "inserted", c.Inserted, "updated", c.Updated, "unchanged", c.Unchanged)
+	return nil
+}
+
+// markPolled records a successful poll of f that stored no articles.
+func (a *AggregatorService) markPolled(ctx context.Context, f domain.Feed, v domain.CacheValidators, hints domain.ScheduleHints, log *slog.Logger) error {
+	return a.repo.MarkFeedPolled(ctx, f.ID, a.pollOutcome(ctx, f, v, hints, log))
+}
+
+// pollOutcome relearns the interval of f from the articles stored so far
+// and schedules its next poll within the publisher's hints.
+func (a *AggregatorService) pollOutcome(ctx context.Context, f domain.Feed, v domain.CacheValidators, hints domain.ScheduleHints, log *slog.Logger) domain.PollOutcome {
+	learned, err := a.learnInterval(ctx, f)
+	if err != nil {
+		log.Error("learn interval", "err", err)
+		learned = f.LearnedInterval
+	}
+	f.LearnedInterval = learned
+	next := hints.Delay(a.pollInterval(f), time.Now())
+	log.Debug("scheduled next poll", "in", next, "learned_interval", learned)
+	return domain.PollOutcome{
+		Validators:      v,
+		NextPoll:        next,
+		LearnedInterval: learned,
+		Hints:           hints,
+	}
+}
+
+// handleFetchError records a failed poll. A gone feed is disabled at once;
+// any other failure extends the feed's failure streak and defers its next
+// poll by an exponential backoff, by the publisher's Retry-After, or by the
+// feed's regular interval, whichever is longest, within the feed's schedule
+// hints. Once the streak reaches the configured
+// threshold the feed is disabled.
+func (a *AggregatorService) handleFetchError(ctx context.Context, f domain.Feed, err error, log *slog.Logger) {
+	// interrupted at the shutdown drain deadline, not the feed's fault
+	if ctx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, domain.ErrFeedGone) {
+		if derr := a.repo.DisableFeed(ctx, f.ID, err.Error()); derr != nil {
+			log.Error("disable feed", "err", derr)
+			return
+		}
+		log.Warn("feed gone, disabled", "err", err)
+		return
+	}
+
+	failures := f.ConsecutiveFailures + 1
+	delay := max(backoffDelay(failures, a.opts.BackoffBase, a.opts.BackoffMax), a.pollInterval(f))
+	var fe *domain.FetchError
+	if errors.As(err, &fe) && fe.RetryAfter > delay {
+		delay = fe.RetryAfter
+	}
+	delay = f.Hints.Delay(delay, time.Now())
+	if merr := a.repo.MarkFeedFailed(ctx, f.ID, err.Error(), delay); merr != nil {
+		log.Error("mark feed failed", "err", merr)
+		return
+	}
+	log.Warn("poll failed", "err", err, "failures", failures, "retry_in", delay)
+
+	if a.opts.FailureThreshold > 0 && failures >= a.opts.FailureThreshold {
+		reason := fmt.Sprintf("%d consecutive failures, last: %v", failures, err)
+		if derr := a.repo.DisableFeed(ctx, f.ID, reason); derr != nil {
+			log.Error("disable feed", "err", derr)
+			return
+		}
+		log.Warn("feed disabled", "reason", reason)
+	}
+}
+
+// fetchOutcome classifies a poll for metrics.
+func fetchOutcome(err error, status int) string {
+	switch {
+	case err == nil && status == 304:
+		return "not_modified"
+	case err == nil:
+		return "success"
+	case errors.Is(err, domain.ErrFeedNotFound):
+		return "not_found"
+	case errors.Is(err, domain.ErrFeedGone):
+		return "gone"
+	case errors.Is(err, domain.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, domain.ErrServerError):
+		return "server_error"
+	case errors.Is(err, domain.ErrParse):
+		return "parse_error"
+	case errors.Is(err, domain.ErrUnexpectedReply):
+		return "unexpected_status"
+	case status == 0:
+		return "network_error"
+	default:
+		return "store_error"
+	}
+}
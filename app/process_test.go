@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rsshub/domain"
+	"testing"
+	"time"
+)
+
+// storeFailRepo fails to store articles and records how the poll is
+// marked. Any other call panics on the nil embedded interface.
+type storeFailRepo struct {
+	domain.FeedRepository
+	polled  bool
+	failed  string
+	retryIn time.Duration
+}
+
+func (r *storeFailRepo) UpsertArticles(ctx context.Context, feedID string, articles []domain.Article, o domain.PollOutcome) (domain.UpsertCounts, error) {
+	return domain.UpsertCounts{}, errors.New("connection refused")
+}
+
+func (r *storeFailRepo) MarkFeedPolled(ctx context.Context, feedID string, o domain.PollOutcome) error {
+	r.polled = true
+	return nil
+}
+
+func (r *storeFailRepo) MarkFeedFailed(ctx context.Context, feedID, reason string, retryAfter time.Duration) error {
+	r.failed, r.retryIn = reason, retryAfter
+	return nil
+}
+
+type oneItemFetcher struct{}
+
+func (oneItemFetcher) Fetch(ctx context.Context, f domain.Feed) (domain.FetchResult, error) {
+	return domain.FetchResult{StatusCode: 200, Items: []domain.FetchedItem{{Title: "A", Link: "https://example.com/a"}},
+		Validators: domain.CacheValidators{ETag: `"v2"`}}, nil
+}
+
+func TestStoreFailureBacksOff(t *testing.T) {
+	repo := &storeFailRepo{}
+	a := NewAggregator(repo, oneItemFetcher{}, time.Minute, 1, Options{BackoffBase: time.Hour, BackoffMax: 2 * time.Hour})
+	f := domain.Feed{ID: "f1", Name: "feed", ConsecutiveFailures: 1}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var attempt domain.FetchAttempt
+	if err := a.pollFeed(context.Background(), f, &attempt, log); err == nil {
+		t.Fatal("poll succeeded although the articles were not stored")
+	}
+	if repo.polled {
+		t.Fatal("feed marked polled")
+	}
+	// the second failure in a row backs off 2h, the upper half of it
+	// randomised
+	if repo.failed == "" || repo.retryIn < time.Hour || repo.retryIn > 2*time.Hour {
+		t.Fatalf("marked failed with %q, retry in %v; want a backoff between 1h and 2h", repo.failed, repo.retryIn)
+	}
+}
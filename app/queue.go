@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"rsshub/domain"
+	"sync"
+)
+
+// job is a feed waiting for a worker. Manual refreshes carry the channels
+// of the callers waiting for the outcome.
+type job struct {
+	feed    domain.Feed
+	urgent  bool
+	waiters []chan<- domain.RefreshResult
+}
+
+// finish delivers r to everyone waiting on j. Waiter channels are buffered
+// so an impatient caller never blocks the worker.
+func (j *job) finish(r domain.RefreshResult) {
+	for _, w := range j.waiters {
+		w <- r
+	}
+}
+
+// feedQueue hands feeds from the scheduler and from manual refreshes to the
+// workers. Urgent jobs are always served before scheduled ones. A feed is
+// queued at most once and is never handed to two workers at the same time,
+// so a feed that is still due on the next heartbeat is not polled twice.
+type feedQueue struct {
+	mu        sync.Mutex
+	urgent    []*job
+	scheduled []*job
+	// queued indexes the jobs not yet popped by feed ID
+	queued map[string]*job
+	// active holds the IDs of feeds currently being processed
+	active map[string]struct{}
+	// ready holds a token while jobs may be available
+	ready chan struct{}
+	// held stops pop from handing out jobs while the aggregator is paused
+	held bool
+}
+
+func newFeedQueue() *feedQueue {
+	return &feedQueue{
+		queued: make(map[string]*job),
+		active: make(map[string]struct{}),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// push queues a scheduled poll of f unless f is already queued or being
+// processed, and reports whether the lease the scheduler claimed on f is
+// still needed: by the job it added, by a scheduled job queued earlier or
+// by the job processing f. A queued refresh claims its feed itself when it
+// runs, so it needs no lease yet.
+func (q *feedQueue) push(f domain.Feed) (leased bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j, ok := q.queued[f.ID]; ok {
+		return !j.urgent
+	}
+	if _, ok := q.active[f.ID]; ok {
+		return true
+	}
+	j := &job{feed: f}
+	q.queued[f.ID] = j
+	q.scheduled = append(q.scheduled, j)
+	q.signal()
+	return true
+}
+
+// pushUrgent queues a manual refresh of f ahead of all scheduled work and
+// arranges for its outcome to be sent to w. A scheduled job for the same
+// feed is promoted rather than duplicated.
+func (q *feedQueue) pushUrgent(f domain.Feed, w chan<- domain.RefreshResult) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j, ok := q.queued[f.ID]; ok {
+		j.waiters = append(j.waiters, w)
+		if !j.urgent {
+			j.urgent = true
+			q.scheduled = removeJob(q.scheduled, j)
+			q.urgent = append(q.urgent, j)
+		}
+		return
+	}
+	j := &job{feed: f, urgent: true, waiters: []chan<- domain.RefreshResult{w}}
+	q.queued[f.ID] = j
+	q.urgent = append(q.urgent, j)
+	q.signal()
+}
+
+// pop blocks until a job whose feed is not being processed is available or
+// ctx is done. The caller must hand the job back through done.
+func (q *feedQueue) pop(ctx context.Context) (*job, bool) {
+	for {
+		// a stopping worker takes no new jobs, even if some are ready
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		q.mu.Lock()
+		if j := q.take(); j != nil {
+			// pass the token on so another idle worker looks as well
+			if len(q.urgent)+len(q.scheduled) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return j, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-q.ready:
+		}
+	}
+}
+
+// take removes the first runnable job. It must be called with q.mu held.
+func (q *feedQueue) take() *job {
+	if q.held {
+		return nil
+	}
+	for _, list := range []*[]*job{&q.urgent, &q.scheduled} {
+		for _, j := range *list {
+			if _, busy := q.active[j.feed.ID]; busy {
+				continue
+			}
+			*list = removeJob(*list, j)
+			delete(q.queued, j.feed.ID)
+			q.active[j.feed.ID] = struct{}{}
+			return j
+		}
+	}
+	return nil
+}
+
+// hold stops or resumes handing out jobs. Queued jobs are kept.
+func (q *feedQueue) hold(on bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.held = on
+	if !on {
+		q.signal()
+	}
+}
+
+// done releases the feed of a job returned by pop.
+func (q *feedQueue) done(j *job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, j.feed.ID)
+	// a refresh may have been waiting for this feed to become idle
+	if len(q.urgent)+len(q.scheduled) > 0 {
+		q.signal()
+	}
+}
+
+// len returns the number of jobs waiting for a worker.
+func (q *feedQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.urgent) + len(q.scheduled)
+}
+
+// load is the number of jobs queued or being processed.
+func (q *feedQueue) load() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.urgent) + len(q.scheduled) + len(q.active)
+}
+
+// dropQueued removes every queued job and returns them.
+func (q *feedQueue) dropQueued() []*job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dropped := append(q.urgent, q.scheduled...)
+	q.urgent, q.scheduled = nil, nil
+	for _, j := range dropped {
+		delete(q.queued, j.feed.ID)
+	}
+	return dropped
+}
+
+// reset empties the queue. Waiters of dropped urgent jobs are not told.
+func (q *feedQueue) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.urgent, q.scheduled = nil, nil
+	q.queued = make(map[string]*job)
+	q.active = make(map[string]struct{})
+}
+
+// signal must be called with q.mu held.
+func (q *feedQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func removeJob(list []*job, j *job) []*job {
+	for i, x := range list {
+		if x == j {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rsshub/domain"
+)
+
+// ErrNotRunning is returned by Refresh when the aggregator is not started.
+var ErrNotRunning = errors.New("aggregator is not running")
+
+// Refresh polls the named feeds, or every enabled feed if names is empty,
+// before any scheduled work and waits until all of them are done. Manual
+// refreshes bypass the schedule, backoff and publisher hints.
+func (a *AggregatorService) Refresh(ctx context.Context, names []string) ([]domain.RefreshResult, error) {
+	a.mu.Lock()
+	started, paused, actx := a.started, !a.pausedAt.IsZero(), a.ctx
+	a.mu.Unlock()
+	if !started {
+		return nil, ErrNotRunning
+	}
+	if paused {
+		return nil, ErrPaused
+	}
+
+	feeds, err := a.refreshTargets(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	waits := make([]chan domain.RefreshResult, len(feeds))
+	for i, f := range feeds {
+		waits[i] = make(chan domain.RefreshResult, 1)
+		a.queue.pushUrgent(f, waits[i])
+	}
+
+	results := make([]domain.RefreshResult, len(feeds))
+	for i, w := range waits {
+		select {
+		case results[i] = <-w:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-actx.Done():
+			return nil, ErrNotRunning
+		}
+	}
+	return results, nil
+}
+
+func (a *AggregatorService) refreshTargets(ctx context.Context, names []string) ([]domain.Feed, error) {
+	if len(names) == 0 {
+		all, err := a.repo.ListFeeds(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		feeds := make([]domain.Feed, 0, len(all))
+		for _, f := range all {
+			if f.DisabledAt.IsZero() {
+				feeds = append(feeds, f)
+			}
+		}
+		return feeds, nil
+	}
+
+	feeds := make([]domain.Feed, 0, len(names))
+	for _, name := range names {
+		f, err := a.repo.GetFeedByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("feed %q: %w", name, err)
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"rsshub/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// recentErrorsKept is how many errors Status reports.
+const recentErrorsKept = 10
+
+// runtimeStats is what the aggregator tracks for Status. It has its own
+// lock so workers reporting progress never contend with configuration
+// changes.
+type runtimeStats struct {
+	mu           sync.Mutex
+	startedAt    time.Time
+	lastTick     time.Time
+	nextWorkerID int
+	workers      map[int]*domain.WorkerStatus
+	errors       []domain.FeedError
+}
+
+// addWorker registers a new idle worker and returns its ID.
+func (s *runtimeStats) addWorker() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextWorkerID++
+	if s.workers == nil {
+		s.workers = make(map[int]*domain.WorkerStatus)
+	}
+	s.workers[s.nextWorkerID] = &domain.WorkerStatus{ID: s.nextWorkerID}
+	return s.nextWorkerID
+}
+
+func (s *runtimeStats) removeWorker(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.workers, id)
+}
+
+// setWorkerFeed records that worker id is processing feed, or is idle if
+// feed is empty.
+func (s *runtimeStats) setWorkerFeed(id int, feed string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.workers[id]; ok {
+		w.Feed = feed
+		w.Since = time.Now()
+	}
+}
+
+func (s *runtimeStats) tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastTick = time.Now()
+}
+
+func (s *runtimeStats) recordError(feed, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, domain.FeedError{At: time.Now(), Feed: feed, Error: msg})
+	if len(s.errors) > recentErrorsKept {
+		s.errors = s.errors[len(s.errors)-recentErrorsKept:]
+	}
+}
+
+// Status reports what the aggregator is doing right now.
+func (a *AggregatorService) Status() domain.AggregatorStatus {
+	a.mu.Lock()
+	st := domain.AggregatorStatus{
+		Instance: a.opts.InstanceID,
+		Running:  a.started,
+		Paused:   !a.pausedAt.IsZero(),
+		PausedAt: a.pausedAt,
+		Interval: a.interval,
+	}
+	a.mu.Unlock()
+	st.QueueDepth = a.queue.len()
+
+	a.stats.mu.Lock()
+	defer a.stats.mu.Unlock()
+	st.StartedAt = a.stats.startedAt
+	st.LastTick = a.stats.lastTick
+	for _, w := range a.stats.workers {
+		st.Workers = append(st.Workers, *w)
+	}
+	sort.Slice(st.Workers, func(i, j int) bool { return st.Workers[i].ID < st.Workers[j].ID })
+	// newest first
+	for i := len(a.stats.errors) - 1; i >= 0; i-- {
+		st.RecentErrors = append(st.RecentErrors, a.stats.errors[i])
+	}
+	return st
+}
@@ -2,20 +2,66 @@ package control
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"net"
 	"net/http"
+	"rsshub/domain"
+	"strings"
 	"time"
 )
 
-type Client struct{ addr string }
+type Client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// NewClient creates a client for the control server at addr, which is
+// either host:port or unix:///path/to.sock. token is sent as a bearer
+// token if not empty.
+func NewClient(addr, token string) *Client {
+	c := &Client{base: "http://" + addr, token: token, http: &http.Client{}}
+	if path, ok := unixPath(addr); ok {
+		// the host part is ignored, every request goes to the socket
+		c.base = "http://rsshub"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	}
+	return c
+}
 
-func NewClient(addr string) *Client { return &Client{addr: addr} }
+// do sends a request with a JSON body, or none if body is nil.
+func (c *Client) do(method, path string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.base+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.http.Do(req)
+}
 
 func (c *Client) SetInterval(d time.Duration) (time.Duration, error) {
-	body, _ := json.Marshal(map[string]interface{}{"duration": d.String()})
-	resp, err := http.Post("http://"+c.addr+"/set-interval", "application/json", bytes.NewReader(body))
+	resp, err := c.do(http.MethodPost, "/set-interval", map[string]interface{}{"duration": d.String()})
 	if err != nil {
 		return 0, err
 	}
@@ -30,8 +76,6 @@ func (c *Client) SetInterval(d time.Duration) (time.Duration, error) {
 
 	_ = json.NewDecoder(resp.Body).Decode(&r)
 
-	log.Printf("response fields: %s, %s", r.Old, r.New)
-
 	if r.Old != "" {
 		if old, err := time.ParseDuration(r.Old); err == nil {
 			return old, nil
@@ -41,8 +85,7 @@ func (c *Client) SetInterval(d time.Duration) (time.Duration, error) {
 }
 
 func (c *Client) SetWorkers(n int) (int, error) {
-	body, _ := json.Marshal(map[string]interface{}{"workers": n})
-	resp, err := http.Post("http://"+c.addr+"/set-workers", "application/json", bytes.NewReader(body))
+	resp, err := c.do(http.MethodPost, "/set-workers", map[string]interface{}{"workers": n})
 	if err != nil {
 		return 0, err
 	}
@@ -57,3 +100,124 @@ func (c *Client) SetWorkers(n int) (int, error) {
 	_ = json.NewDecoder(resp.Body).Decode(&r)
 	return r.Old, nil
 }
+
+// Refresh asks the daemon to poll the named feed, or every feed if all is
+// set, and blocks until it reports the results.
+func (c *Client) Refresh(name string, all bool) ([]domain.RefreshResult, error) {
+	resp, err := c.do(http.MethodPost, "/refresh", map[string]interface{}{"name": name, "all": all})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server error: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var r struct {
+		Results []refreshResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	out := make([]domain.RefreshResult, len(r.Results))
+	for i, res := range r.Results {
+		out[i] = domain.RefreshResult{Feed: res.Feed, ItemsNew: res.New, ItemsUpdated: res.Updated, Error: res.Error}
+	}
+	return out, nil
+}
+
+// Pause suspends polling in the daemon and returns when the pause began,
+// which is earlier than now if it was already paused.
+func (c *Client) Pause() (time.Time, error) {
+	return c.pauseCall("/pause")
+}
+
+// Resume resumes polling in the daemon and returns when the pause it ended
+// began, or the zero time if it was not paused.
+func (c *Client) Resume() (time.Time, error) {
+	return c.pauseCall("/resume")
+}
+
+func (c *Client) pauseCall(path string) (time.Time, error) {
+	resp, err := c.do(http.MethodPost, path, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return time.Time{}, fmt.Errorf("server error: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var r struct {
+		PausedAt time.Time `json:"paused_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return time.Time{}, fmt.Errorf("decode response: %w", err)
+	}
+	return r.PausedAt, nil
+}
+
+// Shutdown asks the daemon to stop. It returns once the request is
+// accepted, before the daemon has drained.
+func (c *Client) Shutdown() error {
+	resp, err := c.do(http.MethodPost, "/shutdown", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("server error: %s", resp.Status)
+	}
+	return nil
+}
+
+// DebugFeeds lists the feeds with debug logging on.
+func (c *Client) DebugFeeds() ([]string, error) {
+	resp, err := c.do(http.MethodGet, "/debug", nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDebugFeeds(resp)
+}
+
+// SetFeedDebug switches debug logging of feed and returns the feeds with
+// debug logging on afterwards.
+func (c *Client) SetFeedDebug(feed string, on bool) ([]string, error) {
+	resp, err := c.do(http.MethodPost, "/debug", map[string]interface{}{"feed": feed, "enabled": on})
+	if err != nil {
+		return nil, err
+	}
+	return decodeDebugFeeds(resp)
+}
+
+func decodeDebugFeeds(resp *http.Response) ([]string, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server error: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var r struct {
+		Feeds []string `json:"feeds"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return r.Feeds, nil
+}
+
+// Status fetches the daemon's current status.
+func (c *Client) Status() (Status, error) {
+	resp, err := c.do(http.MethodGet, "/status", nil)
+	if err != nil {
+		return Status{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return Status{}, fmt.Errorf("server error: %s", resp.Status)
+	}
+	var st Status
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return Status{}, fmt.Errorf("decode response: %w", err)
+	}
+	return st, nil
+}
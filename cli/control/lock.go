@@ -0,0 +1,117 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Lock is an exclusive flock on a PID file. It is held by the running
+// daemon and released by the kernel if the daemon dies, so a lock file left
+// behind by a crash never blocks the next start.
+type Lock struct {
+	f    *os.File
+	path string
+}
+
+// AcquireLock takes the lock at path and writes the current PID into it.
+// If another process holds it, the error wraps ErrAlreadyRunning and names
+// that process.
+func AcquireLock(path string) (*Lock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			pid := readPID(f)
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("%w (pid %d, lock %s)", ErrAlreadyRunning, pid, path)
+			}
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+
+		// the previous owner may have removed the file between our open
+		// and flock, leaving us with a lock nobody else can see
+		if !samePath(f, path) {
+			f.Close()
+			continue
+		}
+
+		if pid := readPID(f); pid != 0 && pid != os.Getpid() {
+			slog.Warn("recovered stale lock", "path", path, "pid", pid)
+		}
+		if err := writePID(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("write lock file: %w", err)
+		}
+		return &Lock{f: f, path: path}, nil
+	}
+}
+
+// Release removes the lock file and drops the lock.
+func (l *Lock) Release() error {
+	// remove while still holding the lock so we never delete a file
+	// another process has locked in the meantime
+	rmErr := os.Remove(l.path)
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if rmErr != nil && !os.IsNotExist(rmErr) {
+		return rmErr
+	}
+	return nil
+}
+
+// LockHolder returns the PID of the process holding the lock at path, or
+// 0 if it is free.
+func LockHolder(path string) (int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err != nil {
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return readPID(f), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+func readPID(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	return pid
+}
+
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// samePath reports whether f is still the file at path.
+func samePath(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, pi)
+}
@@ -0,0 +1,82 @@
+package control
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rsshub.pid")
+
+	if pid, err := LockHolder(path); err != nil || pid != 0 {
+		t.Fatalf("holder of a missing lock: %d, %v", pid, err)
+	}
+
+	// left behind by a crashed daemon: a PID but no flock
+	if err := os.WriteFile(path, []byte("999999\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := LockHolder(path); err != nil || pid != 0 {
+		t.Fatalf("holder of a stale lock: %d, %v", pid, err)
+	}
+	l, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("recovering a stale lock: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("lock file holds %q, want our pid", got)
+	}
+
+	if pid, err := LockHolder(path); err != nil || pid != os.Getpid() {
+		t.Fatalf("holder %d, %v; want %d", pid, err, os.Getpid())
+	}
+	if _, err := AcquireLock(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second acquire: %v, want %v", err, ErrAlreadyRunning)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file left after release: %v", err)
+	}
+	if pid, err := LockHolder(path); err != nil || pid != 0 {
+		t.Fatalf("holder after release: %d, %v", pid, err)
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rsshub.pid")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !samePath(f, path) {
+		t.Fatal("open file not at its own path")
+	}
+
+	// the previous owner released the lock and the next daemon created a
+	// new file in its place, so a lock on f guards nothing
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if samePath(f, path) {
+		t.Fatal("removed file still at its path")
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if samePath(f, path) {
+		t.Fatal("replaced file still at its path")
+	}
+}
@@ -1,34 +1,188 @@
 package control
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"rsshub/domain"
+	"strings"
 	"time"
 )
 
+// ErrAlreadyRunning is returned by AcquireLock when another instance holds
+// the lock.
 var ErrAlreadyRunning = errors.New("already running")
 
-// TryListen tries to bind the control address. If it's already in use, we assume an instance is running.
-func TryListen(addr string) (net.Listener, error) {
-	ln, err := net.Listen("tcp", addr)
+// unixScheme prefixes control addresses that name a Unix domain socket.
+const unixScheme = "unix://"
+
+// Listen binds the control address, either host:port or
+// unix:///path/to.sock. Whether another instance is running is decided by
+// the lock file, not by whether the address is free, so a socket file left
+// by a crashed instance is replaced; anything else at the path, or a
+// socket that is still served, is an error. Only the owner may use the
+// socket.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := unixPath(addr)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if err := checkStaleSocket(path); err != nil {
+		return nil, err
+	}
+	// the socket is bound in a private directory and moved into place once
+	// restricted, so it is never reachable with the umask's permissions;
+	// the umask itself is process-wide and the workers are already running
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".rsshub-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "s")
+	ln, err := net.Listen("unix", tmp)
 	if err != nil {
-		return nil, ErrAlreadyRunning
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("restrict socket: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("move socket into place: %w", err)
+	}
+	return &unixListener{Listener: ln, path: path}, nil
+}
+
+// checkStaleSocket returns an error unless path is free or a socket that
+// nothing accepts connections on.
+func checkStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is in use", path)
+	}
+	return nil
+}
+
+// unixListener removes its socket file when closed, which the listener
+// bound under a temporary name cannot do itself.
+type unixListener struct {
+	net.Listener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// unixPath returns the socket path of a unix:// address.
+func unixPath(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, unixScheme) {
+		return "", false
 	}
-	return ln, nil
+	return strings.TrimPrefix(addr, unixScheme), true
+}
+
+// MetricsWriter renders metrics in the Prometheus text format.
+type MetricsWriter interface {
+	WriteText(w io.Writer) error
+}
+
+// FeedDebugger switches debug logging for single feeds at runtime.
+type FeedDebugger interface {
+	SetFeedDebug(feed string, on bool)
+	DebugFeeds() []string
 }
 
 type Server struct {
-	agg domain.Aggregator
+	agg      domain.Aggregator
+	metrics  MetricsWriter
+	shutdown func()
+	debug    FeedDebugger
+	log      *slog.Logger
+	// tokenSum is the SHA-256 of the bearer token, nil if none is required
+	tokenSum []byte
+}
+
+// NewServer creates a control server. metrics may be nil, in which case
+// /metrics is not served.
+func NewServer(agg domain.Aggregator, metrics MetricsWriter) *Server {
+	return &Server{agg: agg, metrics: metrics, log: slog.Default()}
+}
+
+// WithLogger makes s log requests to l.
+func (s *Server) WithLogger(l *slog.Logger) *Server {
+	s.log = l
+	return s
+}
+
+// WithFeedDebug serves /debug, which switches debug logging of single
+// feeds through d.
+func (s *Server) WithFeedDebug(d FeedDebugger) *Server {
+	s.debug = d
+	return s
+}
+
+// WithToken requires every request to carry token as a bearer token. An
+// empty token disables the check.
+func (s *Server) WithToken(token string) *Server {
+	s.tokenSum = nil
+	if token != "" {
+		sum := sha256.Sum256([]byte(token))
+		s.tokenSum = sum[:]
+	}
+	return s
 }
 
-func NewServer(agg domain.Aggregator) *Server { return &Server{agg: agg} }
+// WithShutdown serves /shutdown, which calls fn to stop the daemon.
+func (s *Server) WithShutdown(fn func()) *Server {
+	s.shutdown = fn
+	return s
+}
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// keep the caller's request ID so logs on both sides can be matched
+	id := r.Header.Get(requestIDHeader)
+	if id == "" {
+		id = newRequestID()
+	}
+	w.Header().Set(requestIDHeader, id)
+	log := s.log.With("request_id", id)
+	r = r.WithContext(context.WithValue(r.Context(), loggerKey{}, log))
+	log.Debug("control request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+
+	if !s.authorized(r) {
+		log.Warn("unauthorized control request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="rsshub"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	switch {
 	case r.Method == http.MethodPost && r.URL.Path == "/set-interval":
 		s.handleSetInterval(w, r)
@@ -36,11 +190,67 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case r.Method == http.MethodPost && r.URL.Path == "/set-workers":
 		s.handleSetWorkers(w, r)
 		return
+	case r.Method == http.MethodPost && r.URL.Path == "/refresh":
+		s.handleRefresh(w, r)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/pause":
+		s.handlePause(w, r)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/resume":
+		s.handleResume(w, r)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/shutdown" && s.shutdown != nil:
+		s.handleShutdown(w, r)
+		return
+	case r.Method == http.MethodGet && r.URL.Path == "/debug" && s.debug != nil:
+		s.handleDebugList(w, r)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/debug" && s.debug != nil:
+		s.handleDebugSet(w, r)
+		return
+	case r.Method == http.MethodGet && r.URL.Path == "/status":
+		s.handleStatus(w, r)
+		return
+	case r.Method == http.MethodGet && r.URL.Path == "/metrics" && s.metrics != nil:
+		s.handleMetrics(w, r)
+		return
 	default:
 		http.NotFound(w, r)
 	}
 }
 
+const requestIDHeader = "X-Request-ID"
+
+type loggerKey struct{}
+
+// requestLog returns the logger of r, which carries its request ID.
+func requestLog(r *http.Request) *slog.Logger {
+	if l, ok := r.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// authorized checks the bearer token. Both sides are hashed first so the
+// comparison takes the same time whatever the length of the guess.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.tokenSum == nil {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare(sum[:], s.tokenSum) == 1
+}
+
 func (s *Server) handleSetInterval(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Duration string `json:"duration"`
@@ -56,10 +266,12 @@ func (s *Server) handleSetInterval(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("duration: %s", req.Duration)
-
 	old := s.agg.CurrentInterval()
-	s.agg.SetInterval(d)
+	if err := s.agg.SetInterval(d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	requestLog(r).Info("interval changed", "old", old, "new", d)
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "old": old.String(), "new": d.String()})
 }
 
@@ -76,5 +288,166 @@ func (s *Server) handleSetWorkers(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	requestLog(r).Info("workers resized", "old", old, "new", req.Workers)
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "old": old, "new": req.Workers})
 }
+
+// refreshResult is the wire form of domain.RefreshResult.
+type refreshResult struct {
+	Feed    string `json:"feed"`
+	New     int    `json:"new"`
+	Updated int    `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		All  bool   `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if (req.Name == "") == !req.All {
+		http.Error(w, "exactly one of name or all is required", http.StatusBadRequest)
+		return
+	}
+
+	var names []string
+	if !req.All {
+		names = []string{req.Name}
+	}
+	log := requestLog(r)
+	log.Info("refresh requested", "feed", req.Name, "all", req.All)
+	results, err := s.agg.Refresh(r.Context(), names)
+	if err != nil {
+		log.Warn("refresh failed", "err", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	out := make([]refreshResult, len(results))
+	for i, res := range results {
+		out[i] = refreshResult{Feed: res.Feed, New: res.ItemsNew, Updated: res.ItemsUpdated, Error: res.Error}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "results": out})
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if err := s.agg.Pause(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	requestLog(r).Info("polling paused")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "paused_at": s.agg.Status().PausedAt})
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	pausedAt := s.agg.Status().PausedAt
+	if err := s.agg.Resume(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	requestLog(r).Info("polling resumed", "paused_at", pausedAt)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "paused_at": pausedAt})
+}
+
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("shutdown requested", "remote", r.RemoteAddr)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+	s.shutdown()
+}
+
+func (s *Server) handleDebugList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"feeds": s.debug.DebugFeeds()})
+}
+
+func (s *Server) handleDebugSet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Feed    string `json:"feed"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if req.Feed == "" {
+		http.Error(w, "feed is required", http.StatusBadRequest)
+		return
+	}
+	s.debug.SetFeedDebug(req.Feed, req.Enabled)
+	requestLog(r).Info("feed debug logging switched", "feed", req.Feed, "enabled", req.Enabled)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "feeds": s.debug.DebugFeeds()})
+}
+
+// Status is the wire form of domain.AggregatorStatus.
+type Status struct {
+	Instance     string         `json:"instance"`
+	Running      bool           `json:"running"`
+	StartedAt    time.Time      `json:"started_at"`
+	Uptime       string         `json:"uptime"`
+	Paused       bool           `json:"paused"`
+	PausedAt     time.Time      `json:"paused_at"`
+	Interval     string         `json:"interval"`
+	Workers      int            `json:"workers"`
+	Busy         int            `json:"busy"`
+	Idle         int            `json:"idle"`
+	WorkerStates []WorkerStatus `json:"worker_states"`
+	QueueDepth   int            `json:"queue_depth"`
+	LastTick     time.Time      `json:"last_tick"`
+	RecentErrors []FeedError    `json:"recent_errors"`
+}
+
+type WorkerStatus struct {
+	ID    int       `json:"id"`
+	Feed  string    `json:"feed,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+type FeedError struct {
+	At    time.Time `json:"at"`
+	Feed  string    `json:"feed,omitempty"`
+	Error string    `json:"error"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	st := s.agg.Status()
+	out := Status{
+		Instance:     st.Instance,
+		Running:      st.Running,
+		StartedAt:    st.StartedAt,
+		Paused:       st.Paused,
+		PausedAt:     st.PausedAt,
+		Interval:     st.Interval.String(),
+		Workers:      s.agg.CurrentWorkers(),
+		WorkerStates: make([]WorkerStatus, 0, len(st.Workers)),
+		QueueDepth:   st.QueueDepth,
+		LastTick:     st.LastTick,
+		RecentErrors: make([]FeedError, 0, len(st.RecentErrors)),
+	}
+	if st.Running {
+		out.Uptime = time.Since(st.StartedAt).Round(time.Second).String()
+	}
+	for _, ws := range st.Workers {
+		if ws.Feed != "" {
+			out.Busy++
+		} else {
+			out.Idle++
+		}
+		out.WorkerStates = append(out.WorkerStates, WorkerStatus{ID: ws.ID, Feed: ws.Feed, Since: ws.Since})
+	}
+	for _, fe := range st.RecentErrors {
+		out.RecentErrors = append(out.RecentErrors, FeedError{At: fe.At, Feed: fe.Feed, Error: fe.Error})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := s.metrics.WriteText(w); err != nil {
+		requestLog(r).Error("write metrics", "err", err)
+	}
+}
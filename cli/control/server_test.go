@@ -0,0 +1,119 @@
+package control
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"rsshub/app"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rsshub.sock")
+	addr := unixScheme + path
+
+	// left behind by a crashed instance
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := Listen(addr)
+	if err != nil {
+		t.Fatalf("replacing a stale socket: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode %v, want 0600", fi.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("%d entries in the socket directory, want the socket only", len(entries))
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close()
+
+	if _, err := Listen(addr); err == nil {
+		t.Fatal("listened on a socket in use")
+	}
+	ln.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket left after close: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(addr); err == nil {
+		t.Fatal("replaced a regular file")
+	}
+	if b, _ := os.ReadFile(path); string(b) != "keep" {
+		t.Fatal("regular file clobbered")
+	}
+}
+
+type staticMetrics string
+
+func (m staticMetrics) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, string(m))
+	return err
+}
+
+func TestServerToken(t *testing.T) {
+	s := NewServer(nil, staticMetrics("up 1\n")).WithToken("secret")
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer guess", http.StatusUnauthorized},
+		{"not bearer", "Basic secret", http.StatusUnauthorized},
+		{"right", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("no WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestSetIntervalRejectsNonPositive(t *testing.T) {
+	agg := app.NewAggregator(nil, nil, time.Minute, 1, app.Options{})
+	s := NewServer(agg, nil)
+	for _, d := range []string{"0s", "-1m"} {
+		r := httptest.NewRequest(http.MethodPost, "/set-interval", strings.NewReader(`{"duration":"`+d+`"}`))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", d, w.Code, http.StatusBadRequest)
+		}
+	}
+	if got := agg.CurrentInterval(); got != time.Minute {
+		t.Fatalf("interval %v after rejected changes, want 1m", got)
+	}
+}
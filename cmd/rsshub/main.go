@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"rsshub/internal/cmd"
+	"rsshub/internal/config"
 	"rsshub/internal/helper"
+	"rsshub/internal/logging"
 
 	_ "github.com/lib/pq"
 )
@@ -19,7 +21,14 @@ func main() {
 	cmdName := os.Args[1]
 	args := os.Args[2:]
 
-	var err error
+	cfg := config.Load()
+	h, err := logging.NewHandler(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(h))
+
 	switch cmdName {
 	case "--help", "-h", "help":
 		helper.PrintHelp()
@@ -27,12 +36,30 @@ func main() {
 		err = cmd.Fetch(args)
 	case "add":
 		err = cmd.Add(args)
+	case "update":
+		err = cmd.Update(args)
 	case "list":
 		err = cmd.List(args)
 	case "delete":
 		err = cmd.Delete(args)
 	case "articles":
 		err = cmd.Articles(args)
+	case "history":
+		err = cmd.History(args)
+	case "article-history":
+		err = cmd.ArticleHistory(args)
+	case "stop":
+		err = cmd.Stop(args)
+	case "status":
+		err = cmd.Status(args)
+	case "refresh":
+		err = cmd.Refresh(args)
+	case "pause":
+		err = cmd.Pause(args)
+	case "resume":
+		err = cmd.Resume(args)
+	case "debug":
+		err = cmd.Debug(args)
 	case "set-interval":
 		err = cmd.SetInterval(args)
 	case "set-workers":
@@ -44,7 +71,7 @@ func main() {
 	}
 
 	if err != nil {
-		log.Printf("%v", err)
+		slog.Error("command failed", "command", cmdName, "err", err)
 		os.Exit(1)
 	}
 }
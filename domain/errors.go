@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedFormat is returned by fetchers when a payload is not any of
+// the feed formats they know how to parse.
+var ErrUnsupportedFormat = errors.New("unsupported feed format")
+
+// Fetch error classes. Fetchers wrap them in a *FetchError, so callers can
+// branch with errors.Is and still reach the status code with errors.As.
+var (
+	ErrFeedNotFound    = errors.New("feed not found")
+	ErrFeedGone        = errors.New("feed gone")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrServerError     = errors.New("server error")
+	ErrParse           = errors.New("parse error")
+	ErrUnexpectedReply = errors.New("unexpected response")
+)
+
+// FetchError describes a failed fetch.
+type FetchError struct {
+	// Kind is one of the fetch error classes above.
+	Kind error
+	// StatusCode is the HTTP status, or 0 if the failure happened after a
+	// successful response (e.g. while parsing).
+	StatusCode int
+	// RetryAfter is the delay the publisher asked for via Retry-After, or 0.
+	RetryAfter time.Duration
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (HTTP %d)", msg, e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *FetchError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
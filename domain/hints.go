@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Delay stretches a delay of at least d so that it honours the hints: it
+// is never shorter than the declared ttl or update period, and it never
+// ends inside the declared skip hours or days.
+func (h ScheduleHints) Delay(d time.Duration, now time.Time) time.Duration {
+	d = max(d, h.TTL, h.UpdatePeriod)
+	return h.NextAllowed(now.Add(d)).Sub(now)
+}
+
+// NextAllowed returns the first time at or after t that lies outside the
+// skip windows, which are defined in UTC.
+func (h ScheduleHints) NextAllowed(t time.Time) time.Time {
+	if h.SkipHours == 0 && h.SkipDays == 0 {
+		return t
+	}
+	u := t.UTC()
+	for i := 0; i < 8*24; i++ {
+		if h.SkipHours&(1<<u.Hour()) == 0 && h.SkipDays&(1<<u.Weekday()) == 0 {
+			return u
+		}
+		u = u.Truncate(time.Hour).Add(time.Hour)
+	}
+	// every hour of the week is skipped, which no publisher means
+	return t
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleHintsDelay(t *testing.T) {
+	// Tuesday
+	now := time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		hints ScheduleHints
+		d     time.Duration
+		want  time.Duration
+	}{
+		{"no hints", ScheduleHints{}, time.Minute, time.Minute},
+		{"ttl longer", ScheduleHints{TTL: time.Hour}, time.Minute, time.Hour},
+		{"ttl shorter", ScheduleHints{TTL: time.Minute}, time.Hour, time.Hour},
+		{"update period", ScheduleHints{TTL: time.Hour, UpdatePeriod: 3 * time.Hour}, time.Minute, 3 * time.Hour},
+		{"outside skip hours", ScheduleHints{SkipHours: 1 << 9}, time.Minute, time.Minute},
+		{"skip hour", ScheduleHints{SkipHours: 1 << 10}, time.Minute, 30 * time.Minute},
+		{"skip hours run", ScheduleHints{SkipHours: 1<<10 | 1<<11 | 1<<12}, time.Minute, 150 * time.Minute},
+		{"skip hours wrap midnight", ScheduleHints{SkipHours: 1<<22 | 1<<23 | 1<<0 | 1<<1}, 12 * time.Hour, 15*time.Hour + 30*time.Minute},
+		{"skip day", ScheduleHints{SkipDays: 1 << time.Tuesday}, time.Minute, 13*time.Hour + 30*time.Minute},
+		{"skip days wrap week", ScheduleHints{SkipDays: 1<<time.Saturday | 1<<time.Sunday}, 4 * 24 * time.Hour, 5*24*time.Hour + 13*time.Hour + 30*time.Minute},
+		{"skip day and hour", ScheduleHints{SkipHours: 1 << 0, SkipDays: 1 << time.Tuesday}, time.Minute, 14*time.Hour + 30*time.Minute},
+		{"ttl into skip hours", ScheduleHints{TTL: 2 * time.Hour, SkipHours: 1 << 12}, time.Minute, 150 * time.Minute},
+		{"everything skipped", ScheduleHints{SkipHours: 1<<24 - 1}, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tt.hints.Delay(tt.d, now); got != tt.want {
+			t.Errorf("%s: Delay(%v) = %v, want %v", tt.name, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestNextAllowedUsesUTC(t *testing.T) {
+	h := ScheduleHints{SkipHours: 1 << 10}
+	// 11:30 in UTC+1 is 10:30 UTC
+	t0 := time.Date(2024, 3, 5, 11, 30, 0, 0, time.FixedZone("", 3600))
+	want := time.Date(2024, 3, 5, 11, 0, 0, 0, time.UTC)
+	if got := h.NextAllowed(t0); !got.Equal(want) {
+		t.Fatalf("NextAllowed(%v) = %v, want %v", t0, got, want)
+	}
+}
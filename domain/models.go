@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Feed struct {
 	ID        string
@@ -8,23 +12,291 @@ type Feed struct {
 	UpdatedAt time.Time
 	Name      string
 	URL       string
+
+	// PollInterval is the feed's own polling interval; zero means the
+	// aggregator's global interval applies.
+	PollInterval time.Duration
+	// LearnedInterval is the interval derived from the feed's observed
+	// publish rate, zero until there is enough history.
+	LearnedInterval time.Duration
+	// NextPollAt is when the feed is next due, zero if it is due now.
+	NextPollAt time.Time
+	// Hints are the schedule hints the feed declared on its last poll.
+	Hints ScheduleHints
+
+	// Validators are the cache validators returned by the last successful
+	// fetch and sent back on the next one as a conditional request.
+	Validators CacheValidators
+
+	// LastError is the error of the most recent poll, empty if it succeeded.
+	LastError string
+	// ConsecutiveFailures is the current streak of failed polls.
+	ConsecutiveFailures int
+	// NextAttemptAt is the earliest time the feed may be polled again, zero
+	// if it is not deferred.
+	NextAttemptAt time.Time
+	// DisabledAt is set once the feed has been disabled; disabled feeds are
+	// never polled.
+	DisabledAt     time.Time
+	DisabledReason string
+}
+
+// ScheduleHints are a publisher's polling preferences declared in the feed
+// itself. Zero values mean no preference.
+type ScheduleHints struct {
+	// TTL is the RSS <ttl>: how long the feed may be cached.
+	TTL time.Duration
+	// UpdatePeriod is derived from sy:updatePeriod and sy:updateFrequency.
+	UpdatePeriod time.Duration
+	// SkipHours has bit h set when the feed must not be polled during hour
+	// h (0-23) UTC.
+	SkipHours uint32
+	// SkipDays has bit d set when the feed must not be polled on
+	// time.Weekday d (UTC).
+	SkipDays uint8
+}
+
+// FeedUpdate lists feed settings to change. Nil fields are left as they are.
+type FeedUpdate struct {
+	URL          *string
+	PollInterval *time.Duration
+	// Enable re-enables a disabled feed and clears its failure streak.
+	Enable bool
+}
+
+// CacheValidators are the HTTP validators a publisher attached to a feed
+// response.
+type CacheValidators struct {
+	ETag         string
+	LastModified string
 }
 
 type Article struct {
+	ID        string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// GUID is the publisher's identifier of the article, or empty. An
+	// article is identified by its GUID within its feed when it has one,
+	// and by its link otherwise.
+	GUID  string
+	Title string
+	Link  string
+	// PublishedAt is the publication date from the feed. When storing,
+	// the zero time stands for a missing date, and the article is dated
+	// FirstSeenAt instead.
+	PublishedAt time.Time
+	FirstSeenAt time.Time
+	Description string
+	// Content is the full content, when the feed carries it apart from
+	// the description.
+	Content    string
+	Authors    []string
+	Categories []string
+	// Comments is the URL of the article's comments page.
+	Comments   string
+	Enclosures []Enclosure
+	FeedID     string
+}
+
+// Enclosure kinds.
+const (
+	// EnclosureFile is an RSS <enclosure>, an Atom enclosure link or a
+	// JSON Feed attachment, such as a podcast episode.
+	EnclosureFile = "enclosure"
+	// EnclosureMedia is a Media RSS media:content.
+	EnclosureMedia = "media"
+	// EnclosureThumbnail is a Media RSS media:thumbnail.
+	EnclosureThumbnail = "thumbnail"
+)
+
+// Enclosure is a media file attached to an article.
+type Enclosure struct {
+	// Kind is one of the enclosure kinds above.
+	Kind string
+	URL  string
+	// Type is the MIME type, or empty.
+	Type string
+	// Length is the size in bytes, or 0 if unknown.
+	Length int64
+	// Duration is the playing time of audio and video, or 0 if unknown.
+	Duration time.Duration
+	// Episode is the podcast episode number from itunes:episode, or 0.
+	Episode int
+}
+
+// ArticleFilter selects articles. Zero fields do not filter.
+type ArticleFilter struct {
+	FeedID string
+	// Author and Category match one of an article's authors or
+	// categories, ignoring case.
+	Author   string
+	Category string
+	Limit    int
+}
+
+// ArticleRevision is a version of an article that a later poll replaced.
+// StoredAt is when the version was stored, ReplacedAt when it was
+// replaced.
+type ArticleRevision struct {
 	ID          string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ArticleID   string
 	Title       string
-	Link        string
-	PublishedAt time.Time
 	Description string
-	FeedID      string
+	Content     string
+	Comments    string
+	Authors     []string
+	Categories  []string
+	Enclosures  []Enclosure
+	PublishedAt time.Time
+	StoredAt    time.Time
+	ReplacedAt  time.Time
 }
 
 // FetchedItem is a simplified representation returned by RSS fetchers.
 type FetchedItem struct {
+	GUID        string
 	Title       string
 	Link        string
 	Description string
+	Content     string
+	// PublishedAt is zero when the item has no usable date.
 	PublishedAt time.Time
+	Authors     []string
+	Categories  []string
+	Comments    string
+	Enclosures  []Enclosure
+}
+
+// FetchResult is the outcome of a single feed fetch.
+type FetchResult struct {
+	Items []FetchedItem
+	// Hints are the schedule hints found in the payload.
+	Hints ScheduleHints
+	// NotModified is set when the publisher answered a conditional request
+	// with 304; Items is empty and nothing needs to be stored.
+	NotModified bool
+	Validators  CacheValidators
+	// MovedTo is the new feed URL when every redirect followed was
+	// permanent (301/308), or empty.
+	MovedTo string
+
+	// StatusCode and Bytes describe the HTTP response. Fetchers set them
+	// whenever a response was received, even if they also return an error.
+	StatusCode int
+	Bytes      int64
+	// Truncated tells what the fetch cut to stay within its limits.
+	Truncated Truncation
+}
+
+// Truncation describes what a fetch cut to stay within its limits.
+type Truncation struct {
+	// Body is set when the body was cut off at the size limit; the items
+	// read before the cut are kept.
+	Body bool
+	// ItemsDropped counts the items beyond the item limit.
+	ItemsDropped int
+	// FieldsCut counts the item fields cut to their length limit.
+	FieldsCut int
+}
+
+// String describes t for logs and the fetch history, or returns an empty
+// string if nothing was cut.
+func (t Truncation) String() string {
+	var parts []string
+	if t.Body {
+		parts = append(parts, "body over size limit")
+	}
+	if t.ItemsDropped > 0 {
+		parts = append(parts, fmt.Sprintf("%d items dropped", t.ItemsDropped))
+	}
+	if t.FieldsCut > 0 {
+		parts = append(parts, fmt.Sprintf("%d fields cut", t.FieldsCut))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PollOutcome is what a successful poll stores on its feed.
+type PollOutcome struct {
+	Validators CacheValidators
+	// NextPoll is the delay until the feed is due again.
+	NextPoll time.Duration
+	// LearnedInterval replaces the feed's learned interval.
+	LearnedInterval time.Duration
+	// Hints replace the feed's stored schedule hints.
+	Hints ScheduleHints
+}
+
+// UpsertCounts tells apart what a batch upsert did with each article.
+// Articles repeated within the batch are only counted once.
+type UpsertCounts struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+// PublishStats summarises the publication times of a feed's most recent
+// articles.
+type PublishStats struct {
+	Count  int
+	Oldest time.Time
+	Newest time.Time
+}
+
+// FetchAttempt is the record of a single poll of a feed.
+type FetchAttempt struct {
+	ID           string
+	FeedID       string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	StatusCode   int
+	Bytes        int64
+	ItemsParsed  int
+	ItemsNew     int
+	ItemsUpdated int
+	// Truncated describes what the fetch cut to stay within its limits,
+	// or is empty.
+	Truncated string
+	// Error is empty for successful polls.
+	Error string
+}
+
+// RefreshResult is the outcome of a manual refresh of one feed.
+type RefreshResult struct {
+	Feed         string
+	ItemsNew     int
+	ItemsUpdated int
+	// Error is empty if the refresh succeeded.
+	Error string
+}
+
+// AggregatorStatus is a snapshot of the background aggregator.
+type AggregatorStatus struct {
+	// Instance identifies the aggregator among those sharing the database.
+	Instance  string
+	Running   bool
+	StartedAt time.Time
+	// Paused is set between Pause and Resume; PausedAt is when it began.
+	Paused     bool
+	PausedAt   time.Time
+	Interval   time.Duration
+	Workers    []WorkerStatus
+	QueueDepth int
+	// LastTick is the last time the scheduler looked for due feeds.
+	LastTick time.Time
+	// RecentErrors are the latest poll errors, newest first.
+	RecentErrors []FeedError
+}
+
+// WorkerStatus describes one worker. Feed is empty while it is idle.
+type WorkerStatus struct {
+	ID    int
+	Feed  string
+	Since time.Time
+}
+
+// FeedError is a poll error kept for status reporting.
+type FeedError struct {
+	At    time.Time
+	Feed  string
+	Error string
 }
@@ -8,27 +8,84 @@ import (
 // FeedRepository is the persistence port for feeds and articles.
 type FeedRepository interface {
 	Ensure(ctx context.Context) error
-	AddFeed(ctx context.Context, name, url string) error
+	AddFeed(ctx context.Context, name, url string, pollInterval time.Duration) error
+	// UpdateFeed applies u to the named feed and returns the number of
+	// feeds changed.
+	UpdateFeed(ctx context.Context, name string, u FeedUpdate) (int64, error)
 	DeleteFeed(ctx context.Context, name string) (int64, error)
 	ListFeeds(ctx context.Context, limit int) ([]Feed, error)
 	GetFeedByName(ctx context.Context, name string) (Feed, error)
 	ListArticlesByFeed(ctx context.Context, feedID string, limit int) ([]Article, error)
-	UpsertArticle(ctx context.Context, a Article) error
-	GetStaleFeeds(ctx context.Context, limit int) ([]Feed, error)
-	MarkFeedPolled(ctx context.Context, feedID string) error
+	// ListArticles returns the articles matching f, newest first.
+	ListArticles(ctx context.Context, f ArticleFilter) ([]Article, error)
+	// UpsertArticles stores the articles of a poll of one feed and marks
+	// the feed polled with o in a single transaction, so either all of it
+	// is applied or none. Articles whose content did not change are left
+	// untouched; the versions that changed ones replace are kept as
+	// revisions.
+	UpsertArticles(ctx context.Context, feedID string, articles []Article, o PollOutcome) (UpsertCounts, error)
+	GetArticle(ctx context.Context, id string) (Article, error)
+	// ListArticleRevisions returns the replaced versions of an article,
+	// newest first.
+	ListArticleRevisions(ctx context.Context, articleID string) ([]ArticleRevision, error)
+	// ClaimDueFeeds leases to owner up to limit enabled feeds whose next
+	// poll is due and that no other owner holds a live lease on, and
+	// returns them most overdue first. A limit <= 0 claims all of them.
+	// Other owners cannot claim the feeds until the lease is released or
+	// expires, so it must outlast a poll.
+	ClaimDueFeeds(ctx context.Context, owner string, limit int, lease time.Duration) ([]Feed, error)
+	// ClaimFeed leases one feed to owner whether it is due or not, and
+	// reports false if another owner holds a live lease on it.
+	ClaimFeed(ctx context.Context, feedID, owner string, lease time.Duration) (bool, error)
+	// ReleaseFeed ends owner's lease on a feed, if it still holds one.
+	ReleaseFeed(ctx context.Context, feedID, owner string) error
+	// ReleaseFeeds ends all of owner's leases.
+	ReleaseFeeds(ctx context.Context, owner string) error
+	// MarkFeedPolled records a successful poll and schedules the next one.
+	MarkFeedPolled(ctx context.Context, feedID string, o PollOutcome) error
+	// MarkFeedFailed records a failed poll and extends the feed's failure
+	// streak, which MarkFeedPolled resets. A positive retryAfter keeps the
+	// feed out of ClaimDueFeeds until it has elapsed.
+	MarkFeedFailed(ctx context.Context, feedID, reason string, retryAfter time.Duration) error
+	UpdateFeedURL(ctx context.Context, feedID, url string) error
+	DisableFeed(ctx context.Context, feedID, reason string) error
+	// PublishStats covers the newest window articles of a feed by
+	// publication time.
+	PublishStats(ctx context.Context, feedID string, window int) (PublishStats, error)
+	RecordFetchAttempt(ctx context.Context, fa FetchAttempt) error
+	ListFetchAttempts(ctx context.Context, feedID string, limit int) ([]FetchAttempt, error)
+	// PruneFetchAttempts deletes attempts started before cutoff.
+	PruneFetchAttempts(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
-// RSSFetcher fetches and parses RSS feeds.
+// RSSFetcher fetches and parses RSS feeds. On error the returned result
+// still carries StatusCode and Bytes when a response was received.
 type RSSFetcher interface {
-	Fetch(ctx context.Context, feedURL string) ([]FetchedItem, error)
+	Fetch(ctx context.Context, feed Feed) (FetchResult, error)
 }
 
 // Aggregator exposes application-level controls for background processing.
 type Aggregator interface {
 	Start(ctx context.Context) error
 	Stop() error
-	SetInterval(d time.Duration)
+	SetInterval(d time.Duration) error
 	Resize(workers int) error
 	CurrentInterval() time.Duration
 	CurrentWorkers() int
+	// Refresh polls the named feeds, or all enabled feeds if names is
+	// empty, ahead of scheduled work and waits for the results.
+	Refresh(ctx context.Context, names []string) ([]RefreshResult, error)
+	// Pause suspends polling until Resume; the workers stay up.
+	Pause() error
+	Resume() error
+	Status() AggregatorStatus
+}
+
+// Metrics receives operational measurements. Implementations must be safe
+// for concurrent use.
+type Metrics interface {
+	// FetchCompleted is reported once per poll with its outcome class.
+	FetchCompleted(feed, outcome string, d time.Duration, bytes int64)
+	ArticlesStored(feed string, inserted, updated int)
+	DBOperation(op string, d time.Duration)
 }
@@ -9,14 +9,17 @@ import (
 	"rsshub/internal/db"
 	"rsshub/internal/helper"
 	"strings"
+	"time"
 )
 
 func Add(args []string) error {
 	fset := flag.NewFlagSet("add", flag.ContinueOnError)
 	var name string
 	var feedURL string
+	var interval time.Duration
 	fset.StringVar(&name, "name", "", "feed name")
 	fset.StringVar(&feedURL, "url", "", "feed URL")
+	fset.DurationVar(&interval, "interval", 0, "polling interval of the feed (0 = global interval)")
 	if err := fset.Parse(args); err != nil {
 		return err
 	}
@@ -29,6 +32,10 @@ func Add(args []string) error {
 		return fmt.Errorf("invalid feed URL: %w", err)
 	}
 
+	if err := helper.IsValidInterval(interval); err != nil {
+		return err
+	}
+
 	cfg := config.Load()
 	database, err := db.OpenDB(cfg)
 	if err != nil {
@@ -41,7 +48,7 @@ func Add(args []string) error {
 		return err
 	}
 
-	if err := repo.AddFeed(context.Background(), name, feedURL); err != nil {
+	if err := repo.AddFeed(context.Background(), name, feedURL, interval); err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
 			return fmt.Errorf("feed %q already exists", name)
 		}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"rsshub/adapter/postgres"
+	"rsshub/domain"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/helper"
+	"slices"
+	"strings"
+	"time"
+)
+
+func ArticleHistory(args []string) error {
+	fset := flag.NewFlagSet("article-history", flag.ContinueOnError)
+	var id string
+	fset.StringVar(&id, "id", "", "article id, as shown by articles")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("--id is required")
+	}
+
+	cfg := config.Load()
+	database, err := db.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	repo := postgres.New(database)
+	if err := repo.Ensure(context.Background()); err != nil {
+		return err
+	}
+
+	article, err := repo.GetArticle(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("article %q not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("could not fetch article %q: %w", id, err)
+	}
+
+	revs, err := repo.ListArticleRevisions(context.Background(), article.ID)
+	if err != nil {
+		return fmt.Errorf("could not fetch revisions of %q: %w", id, err)
+	}
+
+	fmt.Printf("Article: %s\n%s\n\n", article.Title, article.Link)
+	fmt.Printf("Current version, stored %s\n", article.UpdatedAt.Format("2006-01-02 15:04:05"))
+	printArticleVersion(article.Title, article.PublishedAt, article.Description)
+
+	if len(revs) == 0 {
+		fmt.Println("\nNo earlier versions recorded")
+		return nil
+	}
+
+	// each revision is compared with the version that replaced it
+	title, published, desc, content := article.Title, article.PublishedAt, article.Description, article.Content
+	comments, authors, categories, enclosures := article.Comments, article.Authors, article.Categories, enclosureURLs(article.Enclosures)
+	for i, rev := range revs {
+		fmt.Printf("\n%d. Version stored %s, replaced %s\n",
+			i+1,
+			rev.StoredAt.Format("2006-01-02 15:04:05"),
+			rev.ReplacedAt.Format("2006-01-02 15:04:05"),
+		)
+		if rev.Title != title {
+			fmt.Printf("   title: %s\n", helper.WordDiff(rev.Title, title))
+		}
+		if !rev.PublishedAt.Equal(published) {
+			fmt.Printf("   published: [-%s-] {+%s+}\n", rev.PublishedAt.Format("2006-01-02 15:04"), published.Format("2006-01-02 15:04"))
+		}
+		if rev.Description != desc {
+			fmt.Printf("   description: %s\n", helper.WordDiff(rev.Description, desc))
+		}
+		if rev.Content != content {
+			fmt.Printf("   content: %s\n", helper.WordDiff(rev.Content, content))
+		}
+		if rev.Comments != comments {
+			fmt.Printf("   comments: [-%s-] {+%s+}\n", rev.Comments, comments)
+		}
+		printListChange("authors", rev.Authors, authors)
+		printListChange("categories", rev.Categories, categories)
+		printListChange("enclosures", enclosureURLs(rev.Enclosures), enclosures)
+		title, published, desc, content = rev.Title, rev.PublishedAt, rev.Description, rev.Content
+		comments, authors, categories, enclosures = rev.Comments, rev.Authors, rev.Categories, enclosureURLs(rev.Enclosures)
+	}
+	return nil
+}
+
+func printArticleVersion(title string, published time.Time, desc string) {
+	fmt.Printf("   title: %s\n   published: %s\n", title, published.Format("2006-01-02 15:04"))
+	if desc != "" {
+		fmt.Printf("   description: %s\n", desc)
+	}
+}
+
+// printListChange prints the old and new values of a list field that
+// differs between two versions.
+func printListChange(field string, old, cur []string) {
+	if slices.Equal(old, cur) {
+		return
+	}
+	fmt.Printf("   %s: [-%s-] {+%s+}\n", field, strings.Join(old, ", "), strings.Join(cur, ", "))
+}
+
+func enclosureURLs(enclosures []domain.Enclosure) []string {
+	urls := make([]string, len(enclosures))
+	for i, e := range enclosures {
+		urls[i] = e.URL
+	}
+	return urls
+}
@@ -2,26 +2,34 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
 	"rsshub/adapter/postgres"
+	"rsshub/domain"
 	"rsshub/internal/config"
 	"rsshub/internal/db"
 	"strings"
+	"time"
 )
 
 func Articles(args []string) error {
 	fset := flag.NewFlagSet("articles", flag.ContinueOnError)
-	var feedName string
+	var feedName, author, category string
 	var num int
+	var asJSON bool
 	fset.StringVar(&feedName, "feed-name", "", "feed name")
+	fset.StringVar(&author, "author", "", "only articles by this author")
+	fset.StringVar(&category, "category", "", "only articles in this category")
 	fset.IntVar(&num, "num", 3, "number of articles")
+	fset.BoolVar(&asJSON, "json", false, "print the articles as JSON")
 	if err := fset.Parse(args); err != nil {
 		return err
 	}
 
-	if strings.TrimSpace(feedName) == "" {
-		return fmt.Errorf("--feed-name is required")
+	if strings.TrimSpace(feedName) == "" && author == "" && category == "" {
+		return fmt.Errorf("--feed-name is required unless --author or --category is given")
 	}
 
 	cfg := config.Load()
@@ -36,29 +44,133 @@ func Articles(args []string) error {
 		return err
 	}
 
-	feed, err := repo.GetFeedByName(context.Background(), feedName)
-	if err != nil {
-		return fmt.Errorf("feed %q not found", feedName)
+	filter := domain.ArticleFilter{Author: author, Category: category, Limit: num}
+	title := "Articles"
+	if feedName != "" {
+		feed, err := repo.GetFeedByName(context.Background(), feedName)
+		if err != nil {
+			return fmt.Errorf("feed %q not found", feedName)
+		}
+		filter.FeedID = feed.ID
+		title = "Articles from feed: " + feed.Name
 	}
 
-	arts, err := repo.ListArticlesByFeed(context.Background(), feed.ID, num)
+	arts, err := repo.ListArticles(context.Background(), filter)
 	if err != nil {
-		return fmt.Errorf("could not fetch articles for %q: %w", feedName, err)
+		return fmt.Errorf("could not fetch articles: %w", err)
+	}
+
+	if asJSON {
+		out := make([]articleJSON, len(arts))
+		for i, a := range arts {
+			out[i] = newArticleJSON(a)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
 	}
 
 	if len(arts) == 0 {
-		fmt.Printf("No articles found for feed %q\n", feedName)
+		fmt.Println("No articles found")
 		return nil
 	}
 
-	fmt.Printf("Articles from feed: %s\n\n", feed.Name)
+	fmt.Printf("%s\n\n", title)
 	for i, a := range arts {
-		fmt.Printf("%d. [%s] %s\n   %s\n\n",
+		fmt.Printf("%d. [%s] %s\n   %s\n",
 			i+1,
 			a.PublishedAt.Format("2006-01-02"),
 			a.Title,
 			a.Link,
 		)
+		if len(a.Authors) > 0 {
+			fmt.Printf("   by: %s\n", strings.Join(a.Authors, ", "))
+		}
+		if len(a.Categories) > 0 {
+			fmt.Printf("   categories: %s\n", strings.Join(a.Categories, ", "))
+		}
+		if a.Comments != "" {
+			fmt.Printf("   comments: %s\n", a.Comments)
+		}
+		for _, e := range a.Enclosures {
+			fmt.Printf("   %s: %s%s\n", e.Kind, e.URL, enclosureDetails(e))
+		}
+		fmt.Printf("   id: %s\n\n", a.ID)
 	}
 	return nil
 }
+
+// enclosureDetails describes what is known of e besides its URL.
+func enclosureDetails(e domain.Enclosure) string {
+	var parts []string
+	if e.Type != "" {
+		parts = append(parts, e.Type)
+	}
+	if e.Length > 0 {
+		parts = append(parts, fmt.Sprintf("%d bytes", e.Length))
+	}
+	if e.Duration > 0 {
+		parts = append(parts, e.Duration.String())
+	}
+	if e.Episode > 0 {
+		parts = append(parts, fmt.Sprintf("episode %d", e.Episode))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// articleJSON is an article as printed by articles --json.
+type articleJSON struct {
+	ID          string          `json:"id"`
+	FeedID      string          `json:"feed_id"`
+	GUID        string          `json:"guid,omitempty"`
+	Title       string          `json:"title"`
+	Link        string          `json:"link"`
+	PublishedAt time.Time       `json:"published_at"`
+	FirstSeenAt time.Time       `json:"first_seen_at"`
+	Description string          `json:"description"`
+	Content     string          `json:"content,omitempty"`
+	Authors     []string        `json:"authors,omitempty"`
+	Categories  []string        `json:"categories,omitempty"`
+	Comments    string          `json:"comments,omitempty"`
+	Enclosures  []enclosureJSON `json:"enclosures,omitempty"`
+}
+
+type enclosureJSON struct {
+	Kind            string `json:"kind"`
+	URL             string `json:"url"`
+	Type            string `json:"type,omitempty"`
+	Length          int64  `json:"length,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	Episode         int    `json:"episode,omitempty"`
+}
+
+func newArticleJSON(a domain.Article) articleJSON {
+	out := articleJSON{
+		ID:          a.ID,
+		FeedID:      a.FeedID,
+		GUID:        a.GUID,
+		Title:       a.Title,
+		Link:        a.Link,
+		PublishedAt: a.PublishedAt,
+		FirstSeenAt: a.FirstSeenAt,
+		Description: a.Description,
+		Content:     a.Content,
+		Authors:     a.Authors,
+		Categories:  a.Categories,
+		Comments:    a.Comments,
+	}
+	for _, e := range a.Enclosures {
+		out.Enclosures = append(out.Enclosures, enclosureJSON{
+			Kind:            e.Kind,
+			URL:             e.URL,
+			Type:            e.Type,
+			Length:          e.Length,
+			DurationSeconds: int64(e.Duration / time.Second),
+			Episode:         e.Episode,
+		})
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"rsshub/cli/control"
+	"rsshub/internal/config"
+	"strings"
+)
+
+func Debug(args []string) error {
+	fs := flag.NewFlagSet("debug", flag.ContinueOnError)
+	name := fs.String("name", "", "feed name")
+	off := fs.Bool("off", false, "switch debug logging off")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+
+	var feeds []string
+	var err error
+	switch {
+	case strings.TrimSpace(*name) != "":
+		feeds, err = c.SetFeedDebug(*name, !*off)
+	case *off:
+		return fmt.Errorf("usage: rsshub debug [--name X [--off]]")
+	default:
+		feeds, err = c.DebugFeeds()
+	}
+	if err != nil {
+		return fmt.Errorf("could not reach the background process (is `rsshub fetch` running?): %w", err)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println("Debug logging is off for all feeds")
+		return nil
+	}
+	fmt.Printf("Debug logging is on for: %s\n", strings.Join(feeds, ", "))
+	return nil
+}
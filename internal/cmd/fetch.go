@@ -4,27 +4,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os/signal"
 	"rsshub/adapter/postgres"
+	"rsshub/adapter/prometheus"
 	"rsshub/adapter/rss"
 	"rsshub/app"
 	"rsshub/cli/control"
 	"rsshub/internal/config"
 	"rsshub/internal/db"
+	"rsshub/internal/logging"
 	"syscall"
 )
 
 func Fetch(args []string) error {
 	cfg := config.Load()
+	log := slog.Default()
 
-	listener, err := control.TryListen(cfg.ControlAddr)
+	lock, err := control.AcquireLock(cfg.LockFile)
 	if err != nil {
 		if errors.Is(err, control.ErrAlreadyRunning) {
-			fmt.Println("Background process is already running")
+			log.Error("background process is already running", "lock", cfg.LockFile)
 			return err
 		}
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer lock.Release()
+
+	listener, err := control.Listen(cfg.ControlAddr)
+	if err != nil {
 		return fmt.Errorf("failed to start control server: %w", err)
 	}
 	defer listener.Close()
@@ -35,21 +44,49 @@ func Fetch(args []string) error {
 	}
 	defer database.Close()
 
-	repo := postgres.New(database)
+	registry := prometheus.NewRegistry()
+	metrics := prometheus.NewMetrics(registry)
+
+	repo := postgres.New(database).WithMetrics(metrics).WithLogger(log.With("component", "postgres"))
 	if err := repo.Ensure(context.Background()); err != nil {
 		return fmt.Errorf("db ensure failed: %w", err)
 	}
 
-	fetcher := rss.NewHTTPFetcher()
-	agg := app.NewAggregator(repo, fetcher, cfg.DefaultInterval, cfg.DefaultWorkers)
-	ctrl := control.NewServer(agg)
+	fetcher := rss.NewHTTPFetcher().WithLogger(log.With("component", "fetcher")).WithLimits(rss.Limits{
+		MaxBodyBytes:        cfg.MaxBodyBytes,
+		MaxItems:            cfg.MaxItems,
+		MaxTitleBytes:       cfg.MaxTitleBytes,
+		MaxLinkBytes:        cfg.MaxLinkBytes,
+		MaxDescriptionBytes: cfg.MaxDescriptionBytes,
+	})
+	agg := app.NewAggregator(repo, fetcher, cfg.DefaultInterval, cfg.DefaultWorkers, app.Options{
+		Heartbeat:        cfg.Heartbeat,
+		DrainTimeout:     cfg.DrainTimeout,
+		InstanceID:       cfg.InstanceID,
+		LeaseDuration:    cfg.LeaseDuration,
+		HistoryRetention: cfg.HistoryRetention,
+		BackoffBase:      cfg.BackoffBase,
+		BackoffMax:       cfg.BackoffMax,
+		FailureThreshold: cfg.FailureThreshold,
+		AdaptiveMin:      cfg.AdaptiveMin,
+		AdaptiveMax:      cfg.AdaptiveMax,
+		Metrics:          metrics,
+		Logger:           log.With("component", "aggregator"),
+	})
+	registerAggregatorGauges(registry, agg)
 
+	// `rsshub stop` takes the same path as SIGTERM
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	ctrl := control.NewServer(agg, registry).WithToken(cfg.ControlToken).WithShutdown(cancel).
+		WithLogger(log.With("component", "control"))
+	if h, ok := log.Handler().(*logging.Handler); ok {
+		ctrl.WithFeedDebug(h)
+	}
 
 	go func() {
 		if err := http.Serve(listener, ctrl); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("control server error: %v", err)
+			log.Error("control server error", "err", err)
 		}
 	}()
 
@@ -57,16 +94,40 @@ func Fetch(args []string) error {
 		return fmt.Errorf("failed to start aggregator: %w", err)
 	}
 
-	fmt.Printf("The background process for fetching feeds has started (interval = %s, workers = %d)\n", cfg.DefaultInterval.String(), cfg.DefaultWorkers)
+	log.Info("background process for fetching feeds has started",
+		"interval", cfg.DefaultInterval, "workers", cfg.DefaultWorkers, "control", cfg.ControlAddr, "instance", agg.Status().Instance)
 
 	<-ctx.Done()
 
-	// Stop gracefully
+	// Stop gracefully, letting feeds being polled finish first
+	log.Info("shutting down, waiting for running fetches", "drain_timeout", cfg.DrainTimeout)
 	if err := agg.Stop(); err != nil {
-		fmt.Printf("Error during shutdown: %v\n", err)
+		log.Error("error during shutdown", "err", err)
 	} else {
-		fmt.Println("Graceful shutdown: aggregator stopped")
+		log.Info("graceful shutdown: aggregator stopped")
 	}
 
 	return nil
 }
+
+// registerAggregatorGauges exposes the aggregator's live state as gauges.
+func registerAggregatorGauges(r *prometheus.Registry, agg *app.AggregatorService) {
+	r.NewGaugeFunc("rsshub_workers", "Configured number of workers.", func() float64 {
+		return float64(agg.CurrentWorkers())
+	})
+	r.NewGaugeFunc("rsshub_workers_busy", "Workers currently processing a feed.", func() float64 {
+		var busy int
+		for _, w := range agg.Status().Workers {
+			if w.Feed != "" {
+				busy++
+			}
+		}
+		return float64(busy)
+	})
+	r.NewGaugeFunc("rsshub_queue_depth", "Feeds waiting for a worker.", func() float64 {
+		return float64(agg.Status().QueueDepth)
+	})
+	r.NewGaugeFunc("rsshub_interval_seconds", "Global polling interval.", func() float64 {
+		return agg.CurrentInterval().Seconds()
+	})
+}
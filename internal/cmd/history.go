@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"rsshub/adapter/postgres"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"strings"
+	"time"
+)
+
+func History(args []string) error {
+	fset := flag.NewFlagSet("history", flag.ContinueOnError)
+	var feedName string
+	var num int
+	fset.StringVar(&feedName, "feed-name", "", "feed name")
+	fset.IntVar(&num, "num", 10, "number of fetch attempts")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(feedName) == "" {
+		return fmt.Errorf("--feed-name is required")
+	}
+
+	cfg := config.Load()
+	database, err := db.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	repo := postgres.New(database)
+	if err := repo.Ensure(context.Background()); err != nil {
+		return err
+	}
+
+	feed, err := repo.GetFeedByName(context.Background(), feedName)
+	if err != nil {
+		return fmt.Errorf("feed %q not found", feedName)
+	}
+
+	attempts, err := repo.ListFetchAttempts(context.Background(), feed.ID, num)
+	if err != nil {
+		return fmt.Errorf("could not fetch history for %q: %w", feedName, err)
+	}
+
+	if len(attempts) == 0 {
+		fmt.Printf("No fetch attempts recorded for feed %q\n", feedName)
+		return nil
+	}
+
+	fmt.Printf("Fetch history for feed: %s\n\n", feed.Name)
+	for i, fa := range attempts {
+		status := "-"
+		if fa.StatusCode != 0 {
+			status = fmt.Sprint(fa.StatusCode)
+		}
+		fmt.Printf("%d. [%s] HTTP %s, %d bytes, %s\n   parsed %d, new %d, updated %d\n",
+			i+1,
+			fa.StartedAt.Format("2006-01-02 15:04:05"),
+			status,
+			fa.Bytes,
+			fa.FinishedAt.Sub(fa.StartedAt).Round(time.Millisecond),
+			fa.ItemsParsed,
+			fa.ItemsNew,
+			fa.ItemsUpdated,
+		)
+		if fa.Truncated != "" {
+			fmt.Printf("   truncated: %s\n", fa.Truncated)
+		}
+		if fa.Error != "" {
+			fmt.Printf("   error: %s\n", fa.Error)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -23,8 +23,12 @@ func SetInterval(args []string) error {
 	if err != nil {
 		return fmt.Errorf("invalid duration: %w", err)
 	}
+	if d <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
 
-	c := control.NewClient(config.Load().ControlAddr)
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
 	old, err := c.SetInterval(d)
 	if err != nil {
 		return fmt.Errorf("could not set interval: %w", err)
@@ -39,14 +39,38 @@ func List(args []string) error {
 		return nil
 	}
 
-	fmt.Println("Available RSS Feeds\n")
+	fmt.Print("Available RSS Feeds\n\n")
 	for i, f := range feeds {
-		fmt.Printf("%d. %s\n   URL: %s\n   Added: %s\n\n",
+		interval := "global"
+		switch {
+		case f.PollInterval > 0:
+			interval = f.PollInterval.String()
+		case f.LearnedInterval > 0:
+			interval = f.LearnedInterval.String() + " (learned)"
+		}
+		fmt.Printf("%d. %s\n   URL: %s\n   Added: %s\n   Interval: %s\n",
 			i+1,
 			f.Name,
 			f.URL,
 			f.CreatedAt.Format("2006-01-02 15:04"),
+			interval,
 		)
+		if hint := max(f.Hints.TTL, f.Hints.UpdatePeriod); hint > 0 {
+			fmt.Printf("   Publisher asks: at most every %s\n", hint)
+		}
+		if !f.NextPollAt.IsZero() && f.DisabledAt.IsZero() {
+			fmt.Printf("   Next poll: %s\n", f.NextPollAt.Format("2006-01-02 15:04"))
+		}
+		switch {
+		case !f.DisabledAt.IsZero():
+			fmt.Printf("   Disabled: %s (%s)\n", f.DisabledAt.Format("2006-01-02 15:04"), f.DisabledReason)
+		case f.ConsecutiveFailures > 0:
+			fmt.Printf("   Failing: %d in a row, last error: %s\n", f.ConsecutiveFailures, f.LastError)
+			if !f.NextAttemptAt.IsZero() {
+				fmt.Printf("   Next attempt: %s\n", f.NextAttemptAt.Format("2006-01-02 15:04"))
+			}
+		}
+		fmt.Println()
 	}
 	return nil
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"rsshub/cli/control"
+	"rsshub/internal/config"
+	"time"
+)
+
+func Pause(args []string) error {
+	fs := flag.NewFlagSet("pause", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+	since, err := c.Pause()
+	if err != nil {
+		return fmt.Errorf("could not pause (is `rsshub fetch` running?): %w", err)
+	}
+	fmt.Printf("Polling paused since %s\n", since.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+func Resume(args []string) error {
+	fs := flag.NewFlagSet("resume", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+	since, err := c.Resume()
+	if err != nil {
+		return fmt.Errorf("could not resume (is `rsshub fetch` running?): %w", err)
+	}
+	if since.IsZero() {
+		fmt.Println("Polling was not paused")
+		return nil
+	}
+	fmt.Printf("Polling resumed after %s\n", time.Since(since).Round(time.Second))
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"rsshub/cli/control"
+	"rsshub/internal/config"
+	"strings"
+)
+
+func Refresh(args []string) error {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	name := fs.String("name", "", "feed name")
+	all := fs.Bool("all", false, "refresh every enabled feed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if (strings.TrimSpace(*name) == "") == !*all {
+		return fmt.Errorf("usage: rsshub refresh --name X | --all")
+	}
+
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+	results, err := c.Refresh(*name, *all)
+	if err != nil {
+		return fmt.Errorf("could not refresh: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No feeds to refresh")
+		return nil
+	}
+
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			fmt.Printf("%s: failed: %s\n", r.Feed, r.Error)
+			continue
+		}
+		fmt.Printf("%s: %d new, %d updated\n", r.Feed, r.ItemsNew, r.ItemsUpdated)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d feeds failed to refresh", failed, len(results))
+	}
+	return nil
+}
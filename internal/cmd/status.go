@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"rsshub/cli/control"
+	"rsshub/internal/config"
+	"time"
+)
+
+func Status(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print raw JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+	st, err := c.Status()
+	if err != nil {
+		return fmt.Errorf("could not get status (is `rsshub fetch` running?): %w", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+
+	state := "stopped"
+	switch {
+	case st.Running && st.Paused:
+		state = fmt.Sprintf("paused (since %s, %s ago)", st.PausedAt.Local().Format("2006-01-02 15:04:05"), time.Since(st.PausedAt).Round(time.Second))
+	case st.Running:
+		state = "running"
+	}
+	fmt.Printf("Aggregator: %s\n", state)
+	fmt.Printf("Instance: %s\n", st.Instance)
+	if st.Running {
+		fmt.Printf("Uptime: %s (since %s)\n", st.Uptime, st.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Interval: %s\n", st.Interval)
+	fmt.Printf("Workers: %d (%d busy, %d idle)\n", st.Workers, st.Busy, st.Idle)
+	fmt.Printf("Queue depth: %d\n", st.QueueDepth)
+	if !st.LastTick.IsZero() {
+		fmt.Printf("Last tick: %s (%s ago)\n", st.LastTick.Local().Format("2006-01-02 15:04:05"), time.Since(st.LastTick).Round(time.Second))
+	}
+
+	if len(st.WorkerStates) > 0 {
+		fmt.Println("\nWorkers:")
+		for _, w := range st.WorkerStates {
+			if w.Feed == "" {
+				fmt.Printf("  #%d idle\n", w.ID)
+				continue
+			}
+			fmt.Printf("  #%d %s (for %s)\n", w.ID, w.Feed, time.Since(w.Since).Round(time.Second))
+		}
+	}
+
+	if len(st.RecentErrors) > 0 {
+		fmt.Println("\nRecent errors:")
+		for _, e := range st.RecentErrors {
+			feed := e.Feed
+			if feed == "" {
+				feed = "scheduler"
+			}
+			fmt.Printf("  [%s] %s: %s\n", e.At.Local().Format("2006-01-02 15:04:05"), feed, e.Error)
+		}
+	}
+	return nil
+}
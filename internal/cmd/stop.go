@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"rsshub/cli/control"
+	"rsshub/internal/config"
+	"time"
+)
+
+func Stop(args []string) error {
+	cfg := config.Load()
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", cfg.DrainTimeout+10*time.Second, "how long to wait for the background process to exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pid, err := control.LockHolder(cfg.LockFile)
+	if err != nil {
+		return fmt.Errorf("could not read lock file: %w", err)
+	}
+	if pid == 0 {
+		fmt.Println("Background process is not running")
+		return nil
+	}
+
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
+	if err := c.Shutdown(); err != nil {
+		return fmt.Errorf("could not request shutdown of pid %d: %w", pid, err)
+	}
+	fmt.Printf("Shutdown requested, waiting for pid %d to exit\n", pid)
+
+	// the daemon holds the lock until it has drained and exited
+	deadline := time.Now().Add(*timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+		holder, err := control.LockHolder(cfg.LockFile)
+		if err != nil {
+			return fmt.Errorf("could not read lock file: %w", err)
+		}
+		if holder == 0 {
+			fmt.Println("Background process stopped")
+			return nil
+		}
+	}
+	return fmt.Errorf("pid %d still running after %s", pid, *timeout)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"rsshub/adapter/postgres"
+	"rsshub/domain"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/helper"
+	"strings"
+	"time"
+)
+
+func Update(args []string) error {
+	fset := flag.NewFlagSet("update", flag.ContinueOnError)
+	var name string
+	var feedURL string
+	var interval time.Duration
+	var enable bool
+	fset.StringVar(&name, "name", "", "feed name")
+	fset.StringVar(&feedURL, "url", "", "new feed URL")
+	fset.DurationVar(&interval, "interval", 0, "polling interval of the feed (0 = global interval)")
+	fset.BoolVar(&enable, "enable", false, "re-enable a disabled feed")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	var u domain.FeedUpdate
+	fset.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "url":
+			u.URL = &feedURL
+		case "interval":
+			u.PollInterval = &interval
+		}
+	})
+	u.Enable = enable
+	if u.URL == nil && u.PollInterval == nil && !u.Enable {
+		return fmt.Errorf("nothing to update: use --url, --interval or --enable")
+	}
+
+	if u.URL != nil {
+		if err := helper.IsValidURL(feedURL); err != nil {
+			return fmt.Errorf("invalid feed URL: %w", err)
+		}
+	}
+	if u.PollInterval != nil {
+		if err := helper.IsValidInterval(interval); err != nil {
+			return err
+		}
+	}
+
+	cfg := config.Load()
+	database, err := db.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	repo := postgres.New(database)
+	if err := repo.Ensure(context.Background()); err != nil {
+		return err
+	}
+
+	rows, err := repo.UpdateFeed(context.Background(), name, u)
+	if err != nil {
+		return fmt.Errorf("could not update feed %q: %w", name, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("feed %q not found", name)
+	}
+
+	fmt.Printf("Feed %q updated successfully\n", name)
+	return nil
+}
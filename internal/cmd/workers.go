@@ -18,7 +18,8 @@ func SetWorkers(args []string) error {
 		return fmt.Errorf("number of workers should be between 1 and 15")
 	}
 
-	c := control.NewClient(config.Load().ControlAddr)
+	cfg := config.Load()
+	c := control.NewClient(cfg.ControlAddr, cfg.ControlToken)
 	old, err := c.SetWorkers(*count)
 	if err != nil {
 		return fmt.Errorf("could not set workers: %w", err)
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,28 +12,76 @@ type Config struct {
 	DefaultInterval time.Duration
 	DefaultWorkers  int
 
+	Heartbeat        time.Duration
+	DrainTimeout     time.Duration
+	InstanceID       string
+	LeaseDuration    time.Duration
+	HistoryRetention time.Duration
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	FailureThreshold int
+	AdaptiveMin      time.Duration
+	AdaptiveMax      time.Duration
+
+	// Fetch limits; see rss.Limits.
+	MaxBodyBytes        int64
+	MaxItems            int
+	MaxTitleBytes       int
+	MaxLinkBytes        int
+	MaxDescriptionBytes int
+
 	PGHost     string
 	PGPort     int
 	PGUser     string
 	PGPassword string
 	PGDatabase string
 
-	ControlAddr string
+	LogLevel  string
+	LogFormat string
+
+	ControlAddr  string
+	ControlToken string
+	LockFile     string
 }
 
 func Load() Config {
 	interval := parseDurationEnv("CLI_APP_TIMER_INTERVAL", 3*time.Minute)
 	workers := parseIntEnv("CLI_APP_WORKERS_COUNT", 3)
 	pgPort := parseIntEnv("POSTGRES_PORT", 5432)
+	controlAddr := getenv("CONTROL_ADDR", "127.0.0.1:8088")
+	// keep the lock next to the socket when there is one
+	lockFile := filepath.Join(os.TempDir(), "rsshub.lock")
+	if path, ok := strings.CutPrefix(controlAddr, "unix://"); ok {
+		lockFile = path + ".lock"
+	}
 	return Config{
-		DefaultInterval: interval,
-		DefaultWorkers:  workers,
-		PGHost:          getenv("POSTGRES_HOST", "localhost"),
-		PGPort:          pgPort,
-		PGUser:          getenv("POSTGRES_USER", "postgres"),
-		PGPassword:      getenv("POSTGRES_PASSWORD", "changeme"),
-		PGDatabase:      getenv("POSTGRES_DBNAME", "rsshub"),
-		ControlAddr:     getenv("CONTROL_ADDR", "127.0.0.1:8088"),
+		DefaultInterval:     interval,
+		DefaultWorkers:      workers,
+		Heartbeat:           parseDurationEnv("CLI_APP_SCHEDULER_HEARTBEAT", 10*time.Second),
+		DrainTimeout:        parseDurationEnv("CLI_APP_DRAIN_TIMEOUT", 30*time.Second),
+		InstanceID:          os.Getenv("CLI_APP_INSTANCE_ID"),
+		LeaseDuration:       parseDurationEnv("CLI_APP_LEASE_DURATION", 2*time.Minute),
+		HistoryRetention:    parseDurationEnv("CLI_APP_HISTORY_RETENTION", 7*24*time.Hour),
+		BackoffBase:         parseDurationEnv("CLI_APP_BACKOFF_BASE", time.Minute),
+		BackoffMax:          parseDurationEnv("CLI_APP_BACKOFF_MAX", 6*time.Hour),
+		FailureThreshold:    parseIntEnv("CLI_APP_FAILURE_THRESHOLD", 10),
+		AdaptiveMin:         parseDurationEnv("CLI_APP_ADAPTIVE_MIN", 2*time.Minute),
+		AdaptiveMax:         parseDurationEnv("CLI_APP_ADAPTIVE_MAX", 12*time.Hour),
+		MaxBodyBytes:        int64(parseIntEnv("CLI_APP_MAX_BODY_BYTES", 10<<20)),
+		MaxItems:            parseIntEnv("CLI_APP_MAX_ITEMS", 500),
+		MaxTitleBytes:       parseIntEnv("CLI_APP_MAX_TITLE_BYTES", 1<<10),
+		MaxLinkBytes:        parseIntEnv("CLI_APP_MAX_LINK_BYTES", 2<<10),
+		MaxDescriptionBytes: parseIntEnv("CLI_APP_MAX_DESCRIPTION_BYTES", 64<<10),
+		PGHost:              getenv("POSTGRES_HOST", "localhost"),
+		PGPort:              pgPort,
+		PGUser:              getenv("POSTGRES_USER", "postgres"),
+		PGPassword:          getenv("POSTGRES_PASSWORD", "changeme"),
+		PGDatabase:          getenv("POSTGRES_DBNAME", "rsshub"),
+		LogLevel:            getenv("CLI_APP_LOG_LEVEL", "info"),
+		LogFormat:           getenv("CLI_APP_LOG_FORMAT", "text"),
+		ControlAddr:         controlAddr,
+		ControlToken:        os.Getenv("CONTROL_TOKEN"),
+		LockFile:            getenv("CONTROL_LOCK_FILE", lockFile),
 	}
 }
 
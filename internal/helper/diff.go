@@ -0,0 +1,43 @@
+package helper
+
+import "strings"
+
+// WordDiff compares old and new word by word and returns new with removed
+// words marked as [-word-] and added ones as {+word+}.
+func WordDiff(old, new string) string {
+	a, b := strings.Fields(old), strings.Fields(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "[-"+a[i]+"-]")
+			i++
+		default:
+			out = append(out, "{+"+b[j]+"+}")
+			j++
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -7,11 +7,20 @@ func PrintHelp() {
   rsshub COMMAND [OPTIONS]
 
 Commands:
-   add             add new RSS feed (--name, --url)
+   add             add new RSS feed (--name, --url) [--interval 10m]
+   update          change a feed (--name) [--url, --interval, --enable]
    list            list available RSS feeds [--num N]
    delete          delete RSS feed (--name)
-   articles        show latest articles (--feed-name, --num)
+   articles        show latest articles (--feed-name | --author | --category) [--num N] [--json]
+   history         show recent fetch attempts of a feed (--feed-name, --num)
+   article-history show how an article changed between polls (--id)
    fetch           start background fetching
+   stop            stop background fetching and wait for it to exit [--timeout 40s]
+   status          show what the background process is doing [--json]
+   refresh         poll feeds now and wait for the result (--name | --all)
+   pause           suspend polling without stopping the background process
+   resume          resume polling after pause
+   debug           show or switch debug logging of a feed [--name X [--off]]
    set-interval    set RSS fetch interval (--duration 2m)
    set-workers     set number of workers (--count N)
    help            show this help
@@ -30,3 +30,18 @@ func IsValidURL(feedURL string) error {
 
 	return nil
 }
+
+// IsValidInterval checks a per-feed polling interval. Zero selects the
+// global interval; anything else must be whole seconds of at least a minute.
+func IsValidInterval(d time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	if d < time.Minute {
+		return fmt.Errorf("interval must be at least 1m, got %s", d)
+	}
+	if d%time.Second != 0 {
+		return fmt.Errorf("interval must be a whole number of seconds, got %s", d)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package logging sets up the structured logger shared by all commands.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FeedKey is the attribute that names the feed a logger is about. Debug
+// records of loggers carrying it pass for feeds switched to debug with
+// SetFeedDebug, whatever the configured level.
+const FeedKey = "feed"
+
+// Handler filters records by level, except for the debug feeds.
+type Handler struct {
+	inner slog.Handler
+	level slog.Level
+	debug *feedSet
+	// feed is the FeedKey attribute added through WithAttrs, if any
+	feed string
+}
+
+// feedSet is shared by a Handler and all handlers derived from it.
+type feedSet struct {
+	mu    sync.RWMutex
+	names map[string]struct{}
+}
+
+// NewHandler creates a handler writing to w. level is debug, info, warn or
+// error; format is text or json.
+func NewHandler(w io.Writer, level, format string) (*Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	// the inner handler sees everything, filtering happens in Enabled
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var inner slog.Handler
+	switch strings.ToLower(format) {
+	case "text", "":
+		inner = slog.NewTextHandler(w, opts)
+	case "json":
+		inner = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, want text or json", format)
+	}
+	return &Handler{inner: inner, level: lvl, debug: &feedSet{names: make(map[string]struct{})}}, nil
+}
+
+func (h *Handler) Enabled(ctx context.Context, l slog.Level) bool {
+	if l >= h.level {
+		return true
+	}
+	if h.feed == "" {
+		return false
+	}
+	h.debug.mu.RLock()
+	defer h.debug.mu.RUnlock()
+	_, ok := h.debug.names[h.feed]
+	return ok
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == FeedKey {
+			c.feed = a.Value.String()
+		}
+	}
+	return &c
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.inner = h.inner.WithGroup(name)
+	return &c
+}
+
+// SetFeedDebug switches debug logging for the named feed on or off.
+func (h *Handler) SetFeedDebug(feed string, on bool) {
+	h.debug.mu.Lock()
+	defer h.debug.mu.Unlock()
+	if on {
+		h.debug.names[feed] = struct{}{}
+	} else {
+		delete(h.debug.names, feed)
+	}
+}
+
+// DebugFeeds returns the feeds with debug logging on, sorted.
+func (h *Handler) DebugFeeds() []string {
+	h.debug.mu.RLock()
+	defer h.debug.mu.RUnlock()
+	out := make([]string, 0, len(h.debug.names))
+	for name := range h.debug.names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
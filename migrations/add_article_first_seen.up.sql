@@ -0,0 +1,3 @@
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS first_seen_at TIMESTAMP;
+UPDATE articles SET first_seen_at = created_at WHERE first_seen_at IS NULL;
+ALTER TABLE articles ALTER COLUMN first_seen_at SET DEFAULT now(), ALTER COLUMN first_seen_at SET NOT NULL;
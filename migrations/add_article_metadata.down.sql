@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS article_categories;
+DROP TABLE IF EXISTS article_authors;
+
+DROP INDEX IF EXISTS articles_feed_link_idx;
+DROP INDEX IF EXISTS articles_feed_item_key_idx;
+-- fails if articles with different GUIDs share a link
+ALTER TABLE articles ADD CONSTRAINT articles_feed_id_link_key UNIQUE (feed_id, link);
+ALTER TABLE articles DROP COLUMN IF EXISTS item_key;
+
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS content;
+ALTER TABLE articles DROP COLUMN IF EXISTS comments;
+ALTER TABLE articles DROP COLUMN IF EXISTS content;
+ALTER TABLE articles DROP COLUMN IF EXISTS guid;
@@ -0,0 +1,28 @@
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS guid TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS comments TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+
+-- articles are identified by GUID when they have one, by link otherwise
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS item_key TEXT;
+UPDATE articles SET item_key = link WHERE item_key IS NULL;
+ALTER TABLE articles ALTER COLUMN item_key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS articles_feed_item_key_idx ON articles (feed_id, item_key);
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS articles_feed_id_link_key;
+CREATE INDEX IF NOT EXISTS articles_feed_link_idx ON articles (feed_id, link);
+
+CREATE TABLE IF NOT EXISTS article_authors (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (article_id, position)
+);
+CREATE INDEX IF NOT EXISTS article_authors_name_idx ON article_authors (lower(name));
+
+CREATE TABLE IF NOT EXISTS article_categories (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (article_id, position)
+);
+CREATE INDEX IF NOT EXISTS article_categories_name_idx ON article_categories (lower(name));
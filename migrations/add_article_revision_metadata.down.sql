@@ -0,0 +1,4 @@
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS enclosures;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS categories;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS authors;
+ALTER TABLE article_revisions DROP COLUMN IF EXISTS comments;
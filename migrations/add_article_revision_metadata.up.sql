@@ -0,0 +1,5 @@
+-- a revision keeps everything its content hash covers
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS comments TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS authors TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS enclosures JSONB NOT NULL DEFAULT '[]';
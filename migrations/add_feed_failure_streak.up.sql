@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0;
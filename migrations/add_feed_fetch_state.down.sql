@@ -0,0 +1,4 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE feeds DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_error;
@@ -0,0 +1,4 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
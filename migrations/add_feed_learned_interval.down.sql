@@ -0,0 +1 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS learned_interval_seconds;
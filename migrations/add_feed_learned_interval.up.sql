@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS learned_interval_seconds INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS lease_owner;
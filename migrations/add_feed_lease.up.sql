@@ -0,0 +1,2 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS lease_owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
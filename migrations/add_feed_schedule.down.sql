@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS next_poll_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS poll_interval_seconds;
@@ -0,0 +1,2 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS poll_interval_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS next_poll_at TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS skip_days;
+ALTER TABLE feeds DROP COLUMN IF EXISTS skip_hours;
+ALTER TABLE feeds DROP COLUMN IF EXISTS update_period_seconds;
+ALTER TABLE feeds DROP COLUMN IF EXISTS ttl_seconds;
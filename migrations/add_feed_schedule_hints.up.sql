@@ -0,0 +1,4 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS ttl_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS update_period_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS skip_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS skip_days INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS etag TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_modified TEXT NOT NULL DEFAULT '';
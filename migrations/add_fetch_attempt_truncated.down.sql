@@ -0,0 +1 @@
+ALTER TABLE fetch_attempts DROP COLUMN IF EXISTS truncated;
@@ -0,0 +1 @@
+ALTER TABLE fetch_attempts ADD COLUMN IF NOT EXISTS truncated TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS article_enclosures (
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    kind TEXT NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    length BIGINT NOT NULL DEFAULT 0,
+    duration_seconds INT NOT NULL DEFAULT 0,
+    episode INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (article_id, position)
+);
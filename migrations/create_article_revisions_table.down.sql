@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS article_revisions;
+ALTER TABLE articles DROP COLUMN IF EXISTS content_hash;
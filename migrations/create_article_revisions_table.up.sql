@@ -0,0 +1,14 @@
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP NOT NULL,
+    content_hash TEXT NOT NULL DEFAULT '',
+    stored_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS article_revisions_article_idx ON article_revisions (article_id, replaced_at DESC);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS fetch_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    http_status INT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    items_parsed INT NOT NULL DEFAULT 0,
+    items_new INT NOT NULL DEFAULT 0,
+    items_updated INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS fetch_attempts_feed_started_idx ON fetch_attempts (feed_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS fetch_attempts_started_idx ON fetch_attempts (started_at);